@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Habibullo-1999/wallet/pkg/grpcserver"
+	"github.com/Habibullo-1999/wallet/pkg/wallet"
+	"github.com/Habibullo-1999/wallet/pkg/walletpb"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	addr := flag.String("addr", ":9998", "address to listen on")
+	dir := flag.String("data", "", "directory to import state from on start and export to on shutdown")
+	auditPath := flag.String("audit", "", "file to append the audit log to")
+	flag.Parse()
+
+	svc := &wallet.Service{}
+	if *auditPath != "" {
+		audit, err := wallet.OpenAuditLog(*auditPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer audit.Close()
+		svc.EnableAudit(audit)
+	}
+	if *dir != "" {
+		err := svc.Import(*dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv := grpc.NewServer()
+	walletpb.RegisterWalletServer(srv, grpcserver.NewServer(svc))
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		srv.GracefulStop()
+	}()
+
+	log.Printf("listening on %s", *addr)
+	err = srv.Serve(listener)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *dir != "" {
+		err = svc.Export(*dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -1,38 +1,355 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/Habibullo-1999/wallet/pkg/types"
 	"github.com/Habibullo-1999/wallet/pkg/wallet"
-
 )
 
+const usage = `usage: wallet [--data dir] [--json] <command> [args]
+
+commands:
+  account register <phone>
+  account show <accountID>
+  deposit <accountID> <amount>
+  pay <accountID> <amount> <category>
+  reject <paymentID>
+  repeat <paymentID>
+  favorite add <paymentID> <name>
+  favorite pay <favoriteID>
+  history <accountID>
+  export <dir> [<time>]
+  import <dir>
+  sum
+  reconcile
+  reconcile adjust <reason>
+  statement <accountID> <from> <to>
+  balance <accountID> <time>
+  diff <oldDir> <newDir>
+  merge <dir> ours|theirs|fail
+
+amounts are in minimal units (dirams)
+merge takes accounts, payments and favorites only: deposits, refunds, fees,
+rejections and transfers are not merged and reconcile may show discrepancies
+dates are YYYY-MM-DD, a statement covers from <= time < to
+times are YYYY-MM-DD (start of the day, UTC) or RFC 3339
+`
+
+var errUsage = errors.New("invalid arguments")
+
 func main() {
-	s := &wallet.Service{}
-	s.RegisterAccount("+992926421509")
-	s.RegisterAccount("+992926421506")
-	s.RegisterAccount("+992926421505")
-	s.Deposit(1, 5_000_00)
-	pay, err := s.Pay(1, 5000, "cat")
-	_, err = s.Pay(1, 5000, "auto")
-	_, err = s.Pay(1, 5000, "auto")
-	_, err = s.Pay(1, 5000, "shop")
-	_, err = s.Pay(1, 5000, "food")
-	_, err = s.Pay(1, 5000, "coffe")
-	
+	err := run(os.Args[1:], os.Stdout)
+	if err == errUsage {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 	if err != nil {
-		log.Print(err)
+		log.Fatal(err)
 	}
+}
 
-	// money := s.SumPayments(5)
-	_, err = s.FilterPayments(pay.AccountID, 5)
-			if err != nil {
-				log.Print(err)	
-			}
+// run загружает состояние из каталога данных, выполняет команду и, если команда меняет
+// состояние, сохраняет его обратно
+func run(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("wallet", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	dir := flags.String("data", "data", "directory with wallet dumps")
+	asJSON := flags.Bool("json", false, "print result as JSON")
+	err := flags.Parse(args)
+	if err != nil {
+		return errUsage
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	svc := &wallet.Service{}
+	err = svc.Import(*dir)
+	if err != nil {
+		return err
+	}
+
+	result, err := execute(svc, args)
+	if err == wallet.ErrMergeConflict {
+		// конфликты печатаются, чтобы было видно, что помешало слиянию
+		printErr := printResult(out, result, *asJSON)
+		if printErr != nil {
+			return printErr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if !readOnly(args) {
+		err = svc.Export(*dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	if result == nil {
+		return nil
+	}
+	return printResult(out, result, *asJSON)
+}
+
+// readOnly сообщает, что команда только читает состояние и каталог данных переписывать не нужно
+func readOnly(args []string) bool {
+	switch args[0] {
+	case "history", "export", "sum", "statement", "balance", "diff":
+		return true
+	case "account":
+		return len(args) > 1 && args[1] == "show"
+	case "reconcile":
+		return len(args) == 1
+	}
+	return false
+}
+
+func printResult(out io.Writer, result interface{}, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	return printText(out, result)
+}
+
+func execute(svc *wallet.Service, args []string) (interface{}, error) {
+	command, args := args[0], args[1:]
+
+	switch {
+	case command == "account" && len(args) == 2 && args[0] == "register":
+		return svc.RegisterAccount(types.Phone(args[1]))
+	case command == "account" && len(args) == 2 && args[0] == "show":
+		accountID, err := parseID(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return svc.FindAccountByID(accountID)
+	case command == "deposit" && len(args) == 2:
+		accountID, err := parseID(args[0])
+		if err != nil {
+			return nil, err
+		}
+		amount, err := parseMoney(args[1])
+		if err != nil {
+			return nil, err
+		}
+		err = svc.Deposit(accountID, amount)
+		if err != nil {
+			return nil, err
+		}
+		return svc.FindAccountByID(accountID)
+	case command == "pay" && len(args) == 3:
+		accountID, err := parseID(args[0])
+		if err != nil {
+			return nil, err
+		}
+		amount, err := parseMoney(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return svc.Pay(accountID, amount, types.PaymentCategory(args[2]))
+	case command == "reject" && len(args) == 1:
+		err := svc.Reject(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return svc.FindPaymentByID(args[0])
+	case command == "repeat" && len(args) == 1:
+		return svc.Repeat(args[0])
+	case command == "favorite" && len(args) == 3 && args[0] == "add":
+		return svc.FavoritePayment(args[1], args[2])
+	case command == "favorite" && len(args) == 2 && args[0] == "pay":
+		return svc.PayFromFavorite(args[1])
+	case command == "history" && len(args) == 1:
+		accountID, err := parseID(args[0])
+		if err != nil {
+			return nil, err
+		}
+		payments, err := svc.ExportAccountHistory(accountID)
+		if payments == nil {
+			payments = []types.Payment{}
+		}
+		return payments, err
+	case command == "export" && len(args) == 1:
+		return nil, svc.Export(args[0])
+	case command == "export" && len(args) == 2:
+		at, err := parseTime(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return nil, svc.ExportAt(args[0], at)
+	case command == "import" && len(args) == 1:
+		if _, err := os.Stat(args[0]); err != nil {
+			return nil, err
+		}
+		return nil, svc.Import(args[0])
+	case command == "sum" && len(args) == 0:
+		return svc.SumPayments(4), nil
+	case command == "reconcile" && len(args) == 0:
+		discrepancies := svc.Reconcile()
+		if discrepancies == nil {
+			discrepancies = []wallet.Discrepancy{}
+		}
+		return discrepancies, nil
+	case command == "reconcile" && len(args) == 2 && args[0] == "adjust":
+		adjustments, err := svc.AdjustDiscrepancies(svc.Reconcile(), args[1])
+		if adjustments == nil {
+			adjustments = []*types.Adjustment{}
+		}
+		return adjustments, err
+	case command == "statement" && len(args) == 3:
+		accountID, err := parseID(args[0])
+		if err != nil {
+			return nil, err
+		}
+		from, err := parseDate(args[1])
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseDate(args[2])
+		if err != nil {
+			return nil, err
+		}
+		return svc.Statement(accountID, from, to)
+	case command == "balance" && len(args) == 2:
+		accountID, err := parseID(args[0])
+		if err != nil {
+			return nil, err
+		}
+		at, err := parseTime(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return svc.BalanceAt(accountID, at)
+	case command == "diff" && len(args) == 2:
+		return wallet.DiffSnapshots(args[0], args[1])
+	case command == "merge" && len(args) == 2:
+		return svc.Merge(args[0], wallet.MergePolicy(args[1]))
+	}
 
-	// s.FavoritePayment(pay.ID, "cat favorite")
+	return nil, errUsage
+}
+
+func parseID(str string) (int64, error) {
+	id, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid account id %q", str)
+	}
+	return id, nil
+}
+
+func parseMoney(str string) (types.Money, error) {
+	amount, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", str)
+	}
+	return types.Money(amount), nil
+}
 
-	// payment, err := s.ExportAccountHistory(pay.AccountID)
+func parseDate(str string) (time.Time, error) {
+	date, err := time.Parse(time.DateOnly, str)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", str)
+	}
+	return date, nil
+}
+
+func parseTime(str string) (time.Time, error) {
+	at, err := time.Parse(time.RFC3339, str)
+	if err == nil {
+		return at, nil
+	}
+	return parseDate(str)
+}
+
+func printText(out io.Writer, result interface{}) error {
+	var err error
+	switch v := result.(type) {
+	case *types.Account:
+		_, err = fmt.Fprintf(out, "account %d\tphone %s\tbalance %d\t%s\n", v.ID, v.Phone, v.Balance, v.Status)
+	case *types.Payment:
+		err = printPayment(out, *v)
+	case []types.Payment:
+		for _, payment := range v {
+			err = printPayment(out, payment)
+			if err != nil {
+				return err
+			}
+		}
+	case *types.Favorite:
+		_, err = fmt.Fprintf(out, "favorite %s\taccount %d\t%q\t%d\t%s\n", v.ID, v.AccountID, v.Name, v.Amount, v.Category)
+	case types.Money:
+		_, err = fmt.Fprintf(out, "%d\n", v)
+	case []wallet.Discrepancy:
+		for _, discrepancy := range v {
+			_, err = fmt.Fprintf(out, "account %d\tbalance %d\texpected %d\tdifference %d\n", discrepancy.AccountID, discrepancy.Balance, discrepancy.Expected, discrepancy.Difference)
+			if err != nil {
+				return err
+			}
+			for _, record := range discrepancy.Records {
+				_, err = fmt.Fprintf(out, "  %s %s\t%d\n", record.Kind, record.ID, record.Amount)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case []*types.Adjustment:
+		for _, adjustment := range v {
+			_, err = fmt.Fprintf(out, "adjustment %s\taccount %d\t%d\t%q\n", adjustment.ID, adjustment.AccountID, adjustment.Amount, adjustment.Reason)
+			if err != nil {
+				return err
+			}
+		}
+	case *wallet.SnapshotDiff:
+		for _, change := range v.Accounts {
+			_, err = fmt.Fprintf(out, "account %d\t%s\n", change.ID, change.Kind)
+			if err != nil {
+				return err
+			}
+		}
+		for _, change := range v.Payments {
+			_, err = fmt.Fprintf(out, "payment %s\t%s\n", change.ID, change.Kind)
+			if err != nil {
+				return err
+			}
+		}
+		for _, change := range v.Favorites {
+			_, err = fmt.Fprintf(out, "favorite %s\t%s\n", change.ID, change.Kind)
+			if err != nil {
+				return err
+			}
+		}
+	case []wallet.MergeConflict:
+		for _, conflict := range v {
+			_, err = fmt.Fprintf(out, "conflict %s %s\tours %v\ttheirs %v\n", conflict.Kind, conflict.ID, conflict.Ours, conflict.Theirs)
+			if err != nil {
+				return err
+			}
+		}
+	case *wallet.Statement:
+		err = v.WriteText(out)
+	default:
+		_, err = fmt.Fprintf(out, "%v\n", v)
+	}
+	return err
+}
 
-	// s.HistoryToFiles(payment, "../data", 5)
+func printPayment(out io.Writer, payment types.Payment) error {
+	_, err := fmt.Fprintf(out, "payment %s\taccount %d\t%d\t%s\t%s\n", payment.ID, payment.AccountID, payment.Amount, payment.Category, payment.Status)
+	return err
 }
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+	"github.com/Habibullo-1999/wallet/pkg/wallet"
+)
+
+func TestRun_success(t *testing.T) {
+	dir := t.TempDir()
+	commands := [][]string{
+		{"account", "register", "+992926421505"},
+		{"account", "register", "+992926421506"},
+		{"deposit", "1", "10000"},
+		{"pay", "1", "3000", "auto"},
+		{"pay", "1", "2000", "shop"},
+	}
+	for _, args := range commands {
+		err := run(append([]string{"--data", dir}, args...), &bytes.Buffer{})
+		if err != nil {
+			t.Errorf("run(%v): error = %v", args, err)
+			return
+		}
+	}
+
+	var out bytes.Buffer
+	err := run([]string{"--data", dir, "--json", "history", "1"}, &out)
+	if err != nil {
+		t.Errorf("run(history): error = %v", err)
+		return
+	}
+	var payments []types.Payment
+	err = json.Unmarshal(out.Bytes(), &payments)
+	if err != nil {
+		t.Errorf("run(history): invalid json %q, error = %v", out.String(), err)
+		return
+	}
+	if len(payments) != 2 {
+		t.Errorf("run(history): expected 2 payments, actual: %v", payments)
+		return
+	}
+
+	out.Reset()
+	err = run([]string{"--data", dir, "sum"}, &out)
+	if err != nil {
+		t.Errorf("run(sum): error = %v", err)
+		return
+	}
+	if strings.TrimSpace(out.String()) != "5000" {
+		t.Errorf("run(sum): expected 5000, actual: %q", out.String())
+		return
+	}
+
+	out.Reset()
+	err = run([]string{"--data", dir, "--json", "account", "show", "2"}, &out)
+	if err != nil {
+		t.Errorf("run(account show): error = %v", err)
+		return
+	}
+	var account types.Account
+	err = json.Unmarshal(out.Bytes(), &account)
+	if err != nil || account.Phone != "+992926421506" {
+		t.Errorf("run(account show): expected second account, actual: %q, error = %v", out.String(), err)
+	}
+}
+
+func TestRun_fail(t *testing.T) {
+	dir := t.TempDir()
+	err := run([]string{"--data", dir, "pay", "1"}, &bytes.Buffer{})
+	if err != errUsage {
+		t.Errorf("run(): must return errUsage, returned = %v", err)
+	}
+
+	err = run([]string{"--data", dir, "deposit", "1", "100"}, &bytes.Buffer{})
+	if err != wallet.ErrAccountNotFound {
+		t.Errorf("run(): must return ErrAccountNotFound, returned = %v", err)
+	}
+}
+
+func TestRun_mergeConflict(t *testing.T) {
+	ours := t.TempDir()
+	theirs := t.TempDir()
+	for dir, phone := range map[string]string{ours: "+992926421505", theirs: "+992926421506"} {
+		err := run([]string{"--data", dir, "account", "register", phone}, &bytes.Buffer{})
+		if err != nil {
+			t.Errorf("run(account register): error = %v", err)
+			return
+		}
+	}
+
+	var out bytes.Buffer
+	err := run([]string{"--data", ours, "merge", theirs, "fail"}, &out)
+	if err != wallet.ErrMergeConflict {
+		t.Errorf("run(merge): must return ErrMergeConflict, returned = %v", err)
+		return
+	}
+	if !strings.HasPrefix(out.String(), "conflict account 1") {
+		t.Errorf("run(merge): conflicts not printed, output = %q", out.String())
+	}
+}
+
+func TestRun_readOnly(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"account", "register", "+992926421505"},
+		{"deposit", "1", "10000"},
+	} {
+		err := run(append([]string{"--data", dir}, args...), &bytes.Buffer{})
+		if err != nil {
+			t.Errorf("run(%v): error = %v", args, err)
+			return
+		}
+	}
+
+	// команды чтения не переписывают каталог данных, удалённый дамп не появится снова
+	err := os.Remove(filepath.Join(dir, "favorites.dump"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"account", "show", "1"}, {"history", "1"}, {"sum"}, {"reconcile"}, {"balance", "1", "2024-01-01"}} {
+		err = run(append([]string{"--data", dir}, args...), &bytes.Buffer{})
+		if err != nil {
+			t.Errorf("run(%v): error = %v", args, err)
+			return
+		}
+		if _, err := os.Stat(filepath.Join(dir, "favorites.dump")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("run(%v): data dir rewritten, stat error = %v", args, err)
+			return
+		}
+	}
+
+	err = run([]string{"--data", dir, "deposit", "1", "100"}, &bytes.Buffer{})
+	if err != nil {
+		t.Errorf("run(deposit): error = %v", err)
+		return
+	}
+	if _, err := os.Stat(filepath.Join(dir, "favorites.dump")); err != nil {
+		t.Errorf("run(deposit): state not exported, stat error = %v", err)
+	}
+}
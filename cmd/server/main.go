@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/server"
+	"github.com/Habibullo-1999/wallet/pkg/wallet"
+)
+
+func main() {
+	addr := flag.String("addr", ":9999", "address to listen on")
+	dir := flag.String("data", "", "directory to import state from on start and export to on shutdown")
+	auditPath := flag.String("audit", "", "file to append the audit log to")
+	flag.Parse()
+
+	svc := &wallet.Service{}
+	if *auditPath != "" {
+		audit, err := wallet.OpenAuditLog(*auditPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer audit.Close()
+		svc.EnableAudit(audit)
+	}
+	if *dir != "" {
+		err := svc.Import(*dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// запросы к сервису выполняются по очереди, поэтому медленный клиент ограничен по времени
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.NewServer(svc),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Printf("listening on %s", *addr)
+		err := srv.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	// даём активным запросам завершиться, прежде чем сохранить состояние
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		log.Print(err)
+	}
+
+	if *dir != "" {
+		err = svc.Export(*dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -1,5 +1,16 @@
 module github.com/Habibullo-1999/wallet
 
-go 1.16
+go 1.25.0
 
-require github.com/google/uuid v1.1.2
+require (
+	github.com/google/uuid v1.6.0
+	google.golang.org/grpc v1.82.1
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	golang.org/x/net v0.53.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478 // indirect
+)
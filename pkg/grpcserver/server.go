@@ -0,0 +1,343 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+	"github.com/Habibullo-1999/wallet/pkg/wallet"
+	"github.com/Habibullo-1999/wallet/pkg/walletpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server реализует walletpb.WalletServer поверх wallet.Service.
+// Service не потокобезопасен, поэтому вызовы к нему выполняются по очереди.
+type Server struct {
+	walletpb.UnimplementedWalletServer
+
+	mu  sync.Mutex
+	svc *wallet.Service
+}
+
+func NewServer(svc *wallet.Service) *Server {
+	return &Server{svc: svc}
+}
+
+// Locker возвращает мьютекс, под которым сервер вызывает Service, для wallet.Scheduler.Lock
+func (s *Server) Locker() sync.Locker {
+	return &s.mu
+}
+
+func (s *Server) RegisterAccount(ctx context.Context, req *walletpb.RegisterAccountRequest) (*walletpb.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, err := s.svc.RegisterAccount(types.Phone(req.GetPhone()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toAccount(account), nil
+}
+
+func (s *Server) FindAccountByID(ctx context.Context, req *walletpb.AccountRequest) (*walletpb.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.account(req.GetAccountId(), nil)
+}
+
+func (s *Server) Deposit(ctx context.Context, req *walletpb.DepositRequest) (*walletpb.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.svc.Deposit(req.GetAccountId(), types.Money(req.GetAmount()))
+	return s.account(req.GetAccountId(), err)
+}
+
+func (s *Server) FreezeAccount(ctx context.Context, req *walletpb.AccountRequest) (*walletpb.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.svc.FreezeAccount(req.GetAccountId())
+	return s.account(req.GetAccountId(), err)
+}
+
+func (s *Server) UnfreezeAccount(ctx context.Context, req *walletpb.AccountRequest) (*walletpb.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.svc.UnfreezeAccount(req.GetAccountId())
+	return s.account(req.GetAccountId(), err)
+}
+
+func (s *Server) CloseAccount(ctx context.Context, req *walletpb.AccountRequest) (*walletpb.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.svc.CloseAccount(req.GetAccountId())
+	return s.account(req.GetAccountId(), err)
+}
+
+func (s *Server) Pay(ctx context.Context, req *walletpb.PayRequest) (*walletpb.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment, err := s.svc.Pay(req.GetAccountId(), types.Money(req.GetAmount()), types.PaymentCategory(req.GetCategory()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPayment(*payment), nil
+}
+
+func (s *Server) FindPaymentByID(ctx context.Context, req *walletpb.PaymentRequest) (*walletpb.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.payment(req.GetPaymentId(), nil)
+}
+
+func (s *Server) Reject(ctx context.Context, req *walletpb.PaymentRequest) (*walletpb.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.svc.Reject(req.GetPaymentId())
+	return s.payment(req.GetPaymentId(), err)
+}
+
+func (s *Server) Repeat(ctx context.Context, req *walletpb.PaymentRequest) (*walletpb.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment, err := s.svc.Repeat(req.GetPaymentId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPayment(*payment), nil
+}
+
+func (s *Server) RefundPayment(ctx context.Context, req *walletpb.RefundRequest) (*walletpb.Refund, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refund, err := s.svc.Refund(req.GetPaymentId(), types.Money(req.GetAmount()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &walletpb.Refund{
+		Id:        refund.ID,
+		PaymentId: refund.PaymentID,
+		AccountId: refund.AccountID,
+		Amount:    int64(refund.Amount),
+	}, nil
+}
+
+func (s *Server) FavoritePayment(ctx context.Context, req *walletpb.FavoritePaymentRequest) (*walletpb.Favorite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	favorite, err := s.svc.FavoritePayment(req.GetPaymentId(), req.GetName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toFavorite(*favorite), nil
+}
+
+func (s *Server) FindFavoriteByID(ctx context.Context, req *walletpb.FavoriteRequest) (*walletpb.Favorite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	favorite, err := s.svc.FindFavoriteByID(req.GetFavoriteId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toFavorite(*favorite), nil
+}
+
+func (s *Server) ListFavorites(ctx context.Context, req *walletpb.AccountRequest) (*walletpb.FavoriteList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	favorites, err := s.svc.FavoritesByAccount(req.GetAccountId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	list := &walletpb.FavoriteList{}
+	for _, favorite := range favorites {
+		list.Favorites = append(list.Favorites, toFavorite(favorite))
+	}
+	return list, nil
+}
+
+func (s *Server) UpdateFavorite(ctx context.Context, req *walletpb.UpdateFavoriteRequest) (*walletpb.Favorite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	favorite, err := s.svc.UpdateFavorite(req.GetFavoriteId(), req.GetName(), types.Money(req.GetAmount()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toFavorite(*favorite), nil
+}
+
+func (s *Server) DeleteFavorite(ctx context.Context, req *walletpb.FavoriteRequest) (*walletpb.DeleteFavoriteResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.svc.DeleteFavorite(req.GetFavoriteId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &walletpb.DeleteFavoriteResponse{}, nil
+}
+
+func (s *Server) PayFromFavorite(ctx context.Context, req *walletpb.FavoriteRequest) (*walletpb.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment, err := s.svc.PayFromFavorite(req.GetFavoriteId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPayment(*payment), nil
+}
+
+func (s *Server) SumPayments(ctx context.Context, req *walletpb.SumPaymentsRequest) (*walletpb.SumPaymentsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goroutines := int(req.GetGoroutines())
+	if goroutines <= 0 {
+		goroutines = 1
+	}
+	return &walletpb.SumPaymentsResponse{Sum: int64(s.svc.SumPayments(goroutines))}, nil
+}
+
+func (s *Server) SumPaymentsWithProgress(req *walletpb.SumPaymentsRequest, stream grpc.ServerStreamingServer[walletpb.Progress]) error {
+	// горутины сервиса читают платежи, поэтому канал дочитывается под мьютексом,
+	// а отправка, которая может ждать медленного клиента, идёт уже без него
+	var parts []*walletpb.Progress
+	s.mu.Lock()
+	for progress := range s.svc.SumPaymentsWithProgress() {
+		parts = append(parts, &walletpb.Progress{
+			Part:   int64(progress.Part),
+			Result: int64(progress.Result),
+		})
+	}
+	s.mu.Unlock()
+
+	for _, progress := range parts {
+		err := stream.Send(progress)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) ExportAccountHistory(req *walletpb.AccountRequest, stream grpc.ServerStreamingServer[walletpb.Payment]) error {
+	s.mu.Lock()
+	payments, err := s.svc.ExportAccountHistory(req.GetAccountId())
+	s.mu.Unlock()
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for _, payment := range payments {
+		err = stream.Send(toPayment(payment))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) account(accountID int64, err error) (*walletpb.Account, error) {
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	account, err := s.svc.FindAccountByID(accountID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toAccount(account), nil
+}
+
+func (s *Server) payment(paymentID string, err error) (*walletpb.Payment, error) {
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	payment, err := s.svc.FindPaymentByID(paymentID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPayment(*payment), nil
+}
+
+// toStatus переводит ошибку сервиса в gRPC статус с подходящим кодом
+func toStatus(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, wallet.ErrAccountNotFound),
+		errors.Is(err, wallet.ErrPaymentNotFound),
+		errors.Is(err, wallet.ErrFavoriteNotFound):
+		code = codes.NotFound
+	case errors.Is(err, wallet.ErrPhoneRegistered),
+		errors.Is(err, wallet.ErrFavoriteNameTaken):
+		code = codes.AlreadyExists
+	case errors.Is(err, wallet.ErrNotEnoughBalance),
+		errors.Is(err, wallet.ErrPaymentRejected),
+		errors.Is(err, wallet.ErrRefundExceedsPayment),
+		errors.Is(err, wallet.ErrAccountFrozen),
+		errors.Is(err, wallet.ErrAccountClosed),
+		errors.Is(err, wallet.ErrAccountNotFrozen),
+		errors.Is(err, wallet.ErrAccountHasBalance),
+		errors.Is(err, wallet.ErrCategoryDisabled),
+		errors.Is(err, wallet.ErrPaymentInReview),
+		errors.Is(err, wallet.ErrPaymentNotInReview):
+		code = codes.FailedPrecondition
+	case errors.Is(err, wallet.ErrPaymentDenied):
+		code = codes.PermissionDenied
+	case errors.Is(err, wallet.ErrAmountMustBePositive),
+		errors.Is(err, wallet.ErrInvalidPhone),
+		errors.Is(err, wallet.ErrInvalidFavoriteName),
+		errors.Is(err, wallet.ErrCategoryNotFound),
+		errors.Is(err, wallet.ErrInvalidCategory):
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, err.Error())
+}
+
+func toAccount(account *types.Account) *walletpb.Account {
+	return &walletpb.Account{
+		Id:      account.ID,
+		Phone:   string(account.Phone),
+		Balance: int64(account.Balance),
+		Status:  string(account.Status),
+	}
+}
+
+func toPayment(payment types.Payment) *walletpb.Payment {
+	return &walletpb.Payment{
+		Id:        payment.ID,
+		AccountId: payment.AccountID,
+		Amount:    int64(payment.Amount),
+		Category:  string(payment.Category),
+		Status:    string(payment.Status),
+	}
+}
+
+func toFavorite(favorite types.Favorite) *walletpb.Favorite {
+	return &walletpb.Favorite{
+		Id:        favorite.ID,
+		AccountId: favorite.AccountID,
+		Name:      favorite.Name,
+		Amount:    int64(favorite.Amount),
+		Category:  string(favorite.Category),
+	}
+}
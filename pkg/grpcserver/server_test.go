@@ -0,0 +1,133 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/Habibullo-1999/wallet/pkg/wallet"
+	"github.com/Habibullo-1999/wallet/pkg/walletpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T) walletpb.WalletClient {
+	listener := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	walletpb.RegisterWalletServer(srv, NewServer(&wallet.Service{}))
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("can't dial bufconn, error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return walletpb.NewWalletClient(conn)
+}
+
+func TestServer_Pay_success(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	account, err := client.RegisterAccount(ctx, &walletpb.RegisterAccountRequest{Phone: "+992926421505"})
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	_, err = client.Deposit(ctx, &walletpb.DepositRequest{AccountId: account.Id, Amount: 10_000})
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+
+	payment, err := client.Pay(ctx, &walletpb.PayRequest{AccountId: account.Id, Amount: 3_000, Category: "auto"})
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	_, err = client.RefundPayment(ctx, &walletpb.RefundRequest{PaymentId: payment.Id, Amount: 1_000})
+	if err != nil {
+		t.Errorf("RefundPayment(): error = %v", err)
+		return
+	}
+
+	stream, err := client.ExportAccountHistory(ctx, &walletpb.AccountRequest{AccountId: account.Id})
+	if err != nil {
+		t.Errorf("ExportAccountHistory(): error = %v", err)
+		return
+	}
+	count := 0
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Errorf("ExportAccountHistory(): error = %v", err)
+			return
+		}
+		count++
+	}
+	if count != 2 {
+		t.Errorf("ExportAccountHistory(): expected payment and refund, actual: %v records", count)
+		return
+	}
+
+	progress, err := client.SumPaymentsWithProgress(ctx, &walletpb.SumPaymentsRequest{})
+	if err != nil {
+		t.Errorf("SumPaymentsWithProgress(): error = %v", err)
+		return
+	}
+	sum := int64(0)
+	for {
+		part, err := progress.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Errorf("SumPaymentsWithProgress(): error = %v", err)
+			return
+		}
+		sum += part.Result
+	}
+	if sum != 3_000 {
+		t.Errorf("SumPaymentsWithProgress(): expected: 3000, actual: %v", sum)
+	}
+}
+
+func TestServer_Pay_fail(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := client.Pay(ctx, &walletpb.PayRequest{AccountId: 1, Amount: 100, Category: "auto"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Pay(): must return NotFound, returned = %v", err)
+		return
+	}
+
+	account, err := client.RegisterAccount(ctx, &walletpb.RegisterAccountRequest{Phone: "+992926421505"})
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	_, err = client.Pay(ctx, &walletpb.PayRequest{AccountId: account.Id, Amount: 100, Category: "auto"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Pay(): must return FailedPrecondition, returned = %v", err)
+		return
+	}
+	_, err = client.RegisterAccount(ctx, &walletpb.RegisterAccountRequest{Phone: "+992926421505"})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("RegisterAccount(): must return AlreadyExists, returned = %v", err)
+	}
+}
@@ -0,0 +1,418 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+	"github.com/Habibullo-1999/wallet/pkg/wallet"
+)
+
+// ActorHeader - заголовок с именем того, кто выполняет запрос; попадает в журнал аудита
+const ActorHeader = "X-Actor"
+
+// MaxBodySize - наибольший размер тела запроса в байтах
+const MaxBodySize = 1 << 20
+
+// Server отдаёт операции wallet.Service по HTTP с телами в JSON.
+// Service не потокобезопасен, поэтому запросы к нему выполняются по очереди.
+type Server struct {
+	mu  sync.Mutex
+	svc *wallet.Service
+}
+
+func NewServer(svc *wallet.Service) *Server {
+	return &Server{svc: svc}
+}
+
+// Locker возвращает мьютекс, под которым сервер вызывает Service, для wallet.Scheduler.Lock
+func (s *Server) Locker() sync.Locker {
+	return &s.mu
+}
+
+type phoneRequest struct {
+	Phone types.Phone `json:"phone"`
+}
+
+type amountRequest struct {
+	Amount types.Money `json:"amount"`
+}
+
+type payRequest struct {
+	Amount   types.Money           `json:"amount"`
+	Category types.PaymentCategory `json:"category"`
+}
+
+type favoriteRequest struct {
+	Name   string      `json:"name"`
+	Amount types.Money `json:"amount"`
+}
+
+type reviewRequest struct {
+	Reason string `json:"reason"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP разбирает путь вида /accounts/{id}/payments и вызывает нужный обработчик
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	// тело читается до захвата мьютекса: медленный клиент не должен держать весь сервис
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.svc.SetActor(r.Header.Get(ActorHeader))
+
+	switch parts[0] {
+	case "accounts":
+		s.handleAccounts(w, r, parts[1:])
+	case "payments":
+		s.handlePayments(w, r, parts[1:])
+	case "favorites":
+		s.handleFavorites(w, r, parts[1:])
+	case "reviews":
+		s.handleReviews(w, r, parts[1:])
+	case "changes":
+		s.handleChanges(w, r, parts[1:])
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w)
+			return
+		}
+		var req phoneRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		account, err := s.svc.RegisterAccount(req.Phone)
+		respond(w, http.StatusCreated, account, err)
+		return
+	}
+
+	accountID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, wallet.ErrAccountNotFound)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	if len(parts) > 2 {
+		notFound(w)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		account, err := s.svc.FindAccountByID(accountID)
+		respond(w, http.StatusOK, account, err)
+	case action == "deposit" && r.Method == http.MethodPost:
+		var req amountRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		err := s.svc.Deposit(accountID, req.Amount)
+		if err != nil {
+			respond(w, http.StatusOK, nil, err)
+			return
+		}
+		account, err := s.svc.FindAccountByID(accountID)
+		respond(w, http.StatusOK, account, err)
+	case action == "payments" && r.Method == http.MethodPost:
+		var req payRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		payment, err := s.svc.Pay(accountID, req.Amount, req.Category)
+		respond(w, http.StatusCreated, payment, err)
+	case action == "history" && r.Method == http.MethodGet:
+		payments, err := s.svc.ExportAccountHistory(accountID)
+		if payments == nil {
+			payments = []types.Payment{}
+		}
+		respond(w, http.StatusOK, payments, err)
+	case action == "favorites" && r.Method == http.MethodGet:
+		favorites, err := s.svc.FavoritesByAccount(accountID)
+		if favorites == nil {
+			favorites = []types.Favorite{}
+		}
+		respond(w, http.StatusOK, favorites, err)
+	case action == "" || action == "deposit" || action == "payments" || action == "history" || action == "favorites":
+		methodNotAllowed(w)
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) handlePayments(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 || len(parts) > 2 {
+		notFound(w)
+		return
+	}
+
+	paymentID := parts[0]
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		payment, err := s.svc.FindPaymentByID(paymentID)
+		respond(w, http.StatusOK, payment, err)
+	case action == "reject" && r.Method == http.MethodPost:
+		err := s.svc.Reject(paymentID)
+		if err != nil {
+			respond(w, http.StatusOK, nil, err)
+			return
+		}
+		payment, err := s.svc.FindPaymentByID(paymentID)
+		respond(w, http.StatusOK, payment, err)
+	case action == "complete" && r.Method == http.MethodPost:
+		err := s.svc.CompletePayment(paymentID)
+		if err != nil {
+			respond(w, http.StatusOK, nil, err)
+			return
+		}
+		payment, err := s.svc.FindPaymentByID(paymentID)
+		respond(w, http.StatusOK, payment, err)
+	case action == "repeat" && r.Method == http.MethodPost:
+		payment, err := s.svc.Repeat(paymentID)
+		respond(w, http.StatusCreated, payment, err)
+	case action == "refund" && r.Method == http.MethodPost:
+		var req amountRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		refund, err := s.svc.Refund(paymentID, req.Amount)
+		respond(w, http.StatusCreated, refund, err)
+	case action == "favorite" && r.Method == http.MethodPost:
+		var req favoriteRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		favorite, err := s.svc.FavoritePayment(paymentID, req.Name)
+		respond(w, http.StatusCreated, favorite, err)
+	case (action == "approve" || action == "decline") && r.Method == http.MethodPost:
+		var req reviewRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		// проверяющий - тот, кто указан в заголовке X-Actor
+		reviewer := r.Header.Get(ActorHeader)
+		var err error
+		if action == "approve" {
+			err = s.svc.ApproveReview(paymentID, reviewer, req.Reason)
+		} else {
+			err = s.svc.DeclineReview(paymentID, reviewer, req.Reason)
+		}
+		if err != nil {
+			respond(w, http.StatusOK, nil, err)
+			return
+		}
+		payment, err := s.svc.FindPaymentByID(paymentID)
+		respond(w, http.StatusOK, payment, err)
+	case action == "" || action == "complete" || action == "reject" || action == "repeat" || action == "refund" || action == "favorite" ||
+		action == "approve" || action == "decline":
+		methodNotAllowed(w)
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) handleReviews(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) != 0 {
+		notFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	reviews := s.svc.PendingReviews()
+	if reviews == nil {
+		reviews = []wallet.PendingReview{}
+	}
+	respond(w, http.StatusOK, reviews, nil)
+}
+
+// handleChanges отдаёт записи после курсора: /changes?since=0&limit=100
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) != 0 {
+		notFound(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+
+	since, limit := int64(0), 0
+	var err error
+	if value := r.URL.Query().Get("since"); value != "" {
+		since, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || since < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid since"))
+			return
+		}
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid limit"))
+			return
+		}
+	}
+	respond(w, http.StatusOK, s.svc.Since(since, limit), nil)
+}
+
+func (s *Server) handleFavorites(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 || len(parts) > 2 {
+		notFound(w)
+		return
+	}
+
+	favoriteID := parts[0]
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		favorite, err := s.svc.FindFavoriteByID(favoriteID)
+		respond(w, http.StatusOK, favorite, err)
+	case action == "" && r.Method == http.MethodPut:
+		var req favoriteRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		favorite, err := s.svc.UpdateFavorite(favoriteID, req.Name, req.Amount)
+		respond(w, http.StatusOK, favorite, err)
+	case action == "" && r.Method == http.MethodDelete:
+		err := s.svc.DeleteFavorite(favoriteID)
+		if err != nil {
+			respond(w, http.StatusOK, nil, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case action == "pay" && r.Method == http.MethodPost:
+		payment, err := s.svc.PayFromFavorite(favoriteID)
+		respond(w, http.StatusCreated, payment, err)
+	case action == "" || action == "pay":
+		methodNotAllowed(w)
+	default:
+		notFound(w)
+	}
+}
+
+// StatusCode возвращает HTTP статус для ошибки сервиса
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, wallet.ErrAccountNotFound),
+		errors.Is(err, wallet.ErrPaymentNotFound),
+		errors.Is(err, wallet.ErrFavoriteNotFound),
+		errors.Is(err, wallet.ErrScheduleNotFound),
+		errors.Is(err, wallet.ErrMerchantNotFound),
+		errors.Is(err, wallet.ErrIdentifierNotFound),
+		errors.Is(err, wallet.ErrFeeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, wallet.ErrPhoneRegistered),
+		errors.Is(err, wallet.ErrFavoriteNameTaken),
+		errors.Is(err, wallet.ErrIdentifierTaken),
+		errors.Is(err, wallet.ErrPaymentRejected),
+		errors.Is(err, wallet.ErrPaymentCompleted),
+		errors.Is(err, wallet.ErrPaymentInReview),
+		errors.Is(err, wallet.ErrPaymentNotInReview),
+		errors.Is(err, wallet.ErrAccountNotFrozen),
+		errors.Is(err, wallet.ErrAccountHasBalance):
+		return http.StatusConflict
+	case errors.Is(err, wallet.ErrAccountFrozen),
+		errors.Is(err, wallet.ErrAccountClosed),
+		errors.Is(err, wallet.ErrPaymentDenied):
+		return http.StatusForbidden
+	case errors.Is(err, wallet.ErrNotEnoughBalance),
+		errors.Is(err, wallet.ErrRefundExceedsPayment),
+		errors.Is(err, wallet.ErrCategoryDisabled),
+		errors.Is(err, wallet.ErrTransferFavorite):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, wallet.ErrAmountMustBePositive),
+		errors.Is(err, wallet.ErrInvalidPhone),
+		errors.Is(err, wallet.ErrInvalidFavoriteName),
+		errors.Is(err, wallet.ErrCategoryNotFound),
+		errors.Is(err, wallet.ErrInvalidCategory),
+		errors.Is(err, wallet.ErrInvalidReview),
+		errors.Is(err, wallet.ErrInvalidIdentifier),
+		errors.Is(err, wallet.ErrTransferToSelf):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+func respond(w http.ResponseWriter, status int, v interface{}, err error) {
+	if err != nil {
+		writeError(w, StatusCode(err), err)
+		return
+	}
+	writeJSON(w, status, v)
+}
+
+func notFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, errors.New("not found"))
+}
+
+func methodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Print(err)
+	}
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+	"github.com/Habibullo-1999/wallet/pkg/wallet"
+)
+
+func do(t *testing.T, ts *httptest.Server, method string, path string, body interface{}, out interface{}) int {
+	var buf bytes.Buffer
+	if body != nil {
+		err := json.NewEncoder(&buf).Encode(body)
+		if err != nil {
+			t.Fatalf("can't encode body, error = %v", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, ts.URL+path, &buf)
+	if err != nil {
+		t.Fatalf("can't create request, error = %v", err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: error = %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		err = json.NewDecoder(resp.Body).Decode(out)
+		if err != nil {
+			t.Fatalf("%s %s: can't decode response, error = %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServer_payments_success(t *testing.T) {
+	ts := httptest.NewServer(NewServer(&wallet.Service{}))
+	defer ts.Close()
+
+	var account types.Account
+	status := do(t, ts, http.MethodPost, "/accounts", phoneRequest{Phone: "+992926421505"}, &account)
+	if status != http.StatusCreated || account.ID != 1 {
+		t.Errorf("POST /accounts: status = %v, account = %v", status, account)
+		return
+	}
+
+	status = do(t, ts, http.MethodPost, "/accounts/1/deposit", amountRequest{Amount: 10_000}, &account)
+	if status != http.StatusOK || account.Balance != 10_000 {
+		t.Errorf("POST /accounts/1/deposit: status = %v, account = %v", status, account)
+		return
+	}
+
+	var payment types.Payment
+	status = do(t, ts, http.MethodPost, "/accounts/1/payments", payRequest{Amount: 3_000, Category: "auto"}, &payment)
+	if status != http.StatusCreated || payment.Amount != 3_000 {
+		t.Errorf("POST /accounts/1/payments: status = %v, payment = %v", status, payment)
+		return
+	}
+
+	var favorite types.Favorite
+	status = do(t, ts, http.MethodPost, "/payments/"+payment.ID+"/favorite", favoriteRequest{Name: "car"}, &favorite)
+	if status != http.StatusCreated || favorite.Name != "car" {
+		t.Errorf("POST /payments/{id}/favorite: status = %v, favorite = %v", status, favorite)
+		return
+	}
+
+	status = do(t, ts, http.MethodPost, "/favorites/"+favorite.ID+"/pay", nil, &payment)
+	if status != http.StatusCreated {
+		t.Errorf("POST /favorites/{id}/pay: status = %v", status)
+		return
+	}
+
+	status = do(t, ts, http.MethodPost, "/payments/"+payment.ID+"/reject", nil, &payment)
+	if status != http.StatusOK || payment.Status != types.PaymentStatusFail {
+		t.Errorf("POST /payments/{id}/reject: status = %v, payment = %v", status, payment)
+		return
+	}
+
+	var history []types.Payment
+	status = do(t, ts, http.MethodGet, "/accounts/1/history", nil, &history)
+	if status != http.StatusOK || len(history) != 2 {
+		t.Errorf("GET /accounts/1/history: status = %v, history = %v", status, history)
+	}
+}
+
+func TestServer_errors(t *testing.T) {
+	svc := &wallet.Service{}
+	account, err := svc.RegisterAccount("+992926421505")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	recipient, err := svc.RegisterAccount("+992926421506")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = svc.Deposit(account.ID, 1_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	transfer, err := svc.Transfer(account.ID, string(recipient.Phone), 100)
+	if err != nil {
+		t.Errorf("Transfer(): error = %v", err)
+		return
+	}
+
+	ts := httptest.NewServer(NewServer(svc))
+	defer ts.Close()
+
+	tests := []struct {
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{http.MethodGet, "/accounts/42", nil, http.StatusNotFound},
+		{http.MethodPost, "/accounts", phoneRequest{Phone: account.Phone}, http.StatusConflict},
+		{http.MethodPost, "/accounts", phoneRequest{Phone: "123"}, http.StatusBadRequest},
+		{http.MethodPost, "/accounts/1/payments", payRequest{Amount: 10_000, Category: "auto"}, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/payments/" + transfer.ID + "/favorite", favoriteRequest{Name: "rent"}, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/accounts", bytes.Repeat([]byte("a"), MaxBodySize+1), http.StatusRequestEntityTooLarge},
+		{http.MethodPost, "/accounts/1/deposit", amountRequest{Amount: -1}, http.StatusBadRequest},
+		{http.MethodPost, "/payments/unknown/reject", nil, http.StatusNotFound},
+		{http.MethodDelete, "/accounts/1", nil, http.StatusMethodNotAllowed},
+		{http.MethodGet, "/unknown", nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		var resp errorResponse
+		status := do(t, ts, tt.method, tt.path, tt.body, &resp)
+		if status != tt.status {
+			t.Errorf("%s %s: expected status %v, actual: %v (%v)", tt.method, tt.path, tt.status, status, resp.Error)
+		}
+	}
+}
+
+func TestServer_reviews_success(t *testing.T) {
+	svc := &wallet.Service{}
+	svc.SetRiskEngine(wallet.NewRiskEngine(wallet.AmountAnomalyRule{Factor: 2, MinHistory: 1}))
+	account, _ := svc.RegisterAccount("+992926421505")
+	svc.Deposit(account.ID, 10_000)
+	svc.Pay(account.ID, 100, "auto")
+	payment, _ := svc.Pay(account.ID, 1_000, "auto")
+
+	ts := httptest.NewServer(NewServer(svc))
+	defer ts.Close()
+
+	var reviews []wallet.PendingReview
+	status := do(t, ts, http.MethodGet, "/reviews", nil, &reviews)
+	if status != http.StatusOK || len(reviews) != 1 || reviews[0].Payment.ID != payment.ID {
+		t.Errorf("GET /reviews: status = %v, reviews = %v", status, reviews)
+		return
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/payments/"+payment.ID+"/approve", bytes.NewBufferString(`{"reason":"checked"}`))
+	req.Header.Set(ActorHeader, "alice")
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Errorf("POST /payments/{id}/approve: error = %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || payment.Status != types.PaymentStatusOk {
+		t.Errorf("POST /payments/{id}/approve: status = %v, payment = %v", resp.StatusCode, payment)
+		return
+	}
+
+	status = do(t, ts, http.MethodPost, "/payments/"+payment.ID+"/decline", reviewRequest{Reason: "late"}, nil)
+	if status != http.StatusBadRequest {
+		t.Errorf("POST /payments/{id}/decline: expected 400 without reviewer, actual: %v", status)
+	}
+}
+
+func TestServer_changes_success(t *testing.T) {
+	svc := &wallet.Service{}
+	account, _ := svc.RegisterAccount("+992926421505")
+	svc.Deposit(account.ID, 10_000)
+	payment, _ := svc.Pay(account.ID, 100, "auto")
+	svc.Pay(account.ID, 200, "auto")
+
+	ts := httptest.NewServer(NewServer(svc))
+	defer ts.Close()
+
+	var records []wallet.SeqRecord
+	status := do(t, ts, http.MethodGet, "/changes?since=1&limit=1", nil, &records)
+	if status != http.StatusOK || len(records) != 1 || records[0].Seq != 2 || records[0].Payment.ID != payment.ID {
+		t.Errorf("GET /changes: status = %v, records = %v", status, records)
+		return
+	}
+
+	status = do(t, ts, http.MethodGet, "/changes?since=x", nil, nil)
+	if status != http.StatusBadRequest {
+		t.Errorf("GET /changes: expected 400 for invalid cursor, actual: %v", status)
+	}
+}
@@ -1,11 +1,41 @@
 package types
 
+import "time"
+
 // Money представляет собой в минимальных единицах (центы, копейки, дирамы и т.д.)
 type Money int64
 
 // PaymentCategory представляет собой категорию, в каторой был совершён платёж (авто, аптеки, рестораны и т.д.).
 type PaymentCategory string
 
+// Category представляет описание категории платежей из справочника.
+// Parent - код родительской категории, пустой для категорий верхнего уровня.
+type Category struct {
+	Code     PaymentCategory `json:"code"`
+	Name     string          `json:"name"`
+	Parent   PaymentCategory `json:"parent"`
+	Enabled  bool            `json:"enabled"`
+	Merchant string          `json:"merchant"`
+}
+
+// Merchant представляет получателя платежей. Balance - сумма, ещё не выплаченная по расчётам.
+type Merchant struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Balance Money  `json:"balance"`
+}
+
+// MerchantEntry представляет изменение баланса мерчанта по платежу: зачисление
+// при оплате или списание при возврате и отмене. BatchID заполняется при расчёте.
+type MerchantEntry struct {
+	ID         string    `json:"id"`
+	MerchantID string    `json:"merchant_id"`
+	PaymentID  string    `json:"payment_id"`
+	Amount     Money     `json:"amount"`
+	Time       time.Time `json:"time"`
+	BatchID    string    `json:"batch_id"`
+}
+
 // PaymentStatus представляет собой статус платёжа
 type PaymentStatus string
 
@@ -13,34 +43,230 @@ const (
 	PaymentStatusOk         PaymentStatus = "OK"
 	PaymentStatusFail       PaymentStatus = "FAIL"
 	PaymentStatusInProgress PaymentStatus = "INPROGREES"
+	PaymentStatusRefund     PaymentStatus = "REFUND"
+	PaymentStatusReview     PaymentStatus = "REVIEW"
 )
 
-//Payment представляет информацию о платеже
+// Payment представляет информацию о платеже
 type Payment struct {
-	ID        string
-	AccountID int64
-	Amount    Money
-	Category  PaymentCategory
-	Status    PaymentStatus
+	ID        string          `json:"id"`
+	Seq       int64           `json:"seq"`
+	Changed   int64           `json:"changed,omitempty"` // номер последнего изменения статуса или возврата
+	AccountID int64           `json:"account_id"`
+	Amount    Money           `json:"amount"`
+	Category  PaymentCategory `json:"category"`
+	Status    PaymentStatus   `json:"status"`
+	Time      time.Time       `json:"time"`
+}
+
+// Refund представляет информацию о частичном возврате по платежу.
+// Fee - часть комиссии, вернувшаяся на счёт вместе с Amount.
+type Refund struct {
+	ID        string    `json:"id"`
+	PaymentID string    `json:"payment_id"`
+	AccountID int64     `json:"account_id"`
+	Amount    Money     `json:"amount"`
+	Fee       Money     `json:"fee"`
+	Time      time.Time `json:"time"`
+}
+
+// Fee представляет комиссию, списанную вместе с платежом.
+// Refunded - часть комиссии, возвращённая при отмене платежа.
+type Fee struct {
+	ID        string `json:"id"`
+	PaymentID string `json:"payment_id"`
+	AccountID int64  `json:"account_id"`
+	Amount    Money  `json:"amount"`
+	Refunded  Money  `json:"refunded"`
+}
+
+// CashbackStatus представляет собой состояние начисленного кэшбэка
+type CashbackStatus string
+
+const (
+	CashbackPending   CashbackStatus = "PENDING"
+	CashbackConfirmed CashbackStatus = "CONFIRMED"
+	CashbackReversed  CashbackStatus = "REVERSED"
+)
+
+// Cashback представляет кэшбэк за платёж. Он ждёт подтверждения платежа
+// и отменяется вместе с платежом. Refunded - часть Amount, снятая при возвратах платежа.
+type Cashback struct {
+	ID        string         `json:"id"`
+	PaymentID string         `json:"payment_id"`
+	AccountID int64          `json:"account_id"`
+	Amount    Money          `json:"amount"`
+	Refunded  Money          `json:"refunded"`
+	Status    CashbackStatus `json:"status"`
+	Time      time.Time      `json:"time"`
+}
+
+// RiskDecision представляет собой решение проверки платежа на мошенничество
+type RiskDecision string
+
+const (
+	RiskAllow  RiskDecision = "ALLOW"
+	RiskDeny   RiskDecision = "DENY"
+	RiskReview RiskDecision = "REVIEW"
+)
+
+// RiskEvent представляет попытку платежа, которую проверка отклонила или отправила на проверку.
+// PaymentID пуст для отклонённых попыток.
+type RiskEvent struct {
+	ID        string          `json:"id"`
+	AccountID int64           `json:"account_id"`
+	Amount    Money           `json:"amount"`
+	Category  PaymentCategory `json:"category"`
+	Time      time.Time       `json:"time"`
+	Decision  RiskDecision    `json:"decision"`
+	Reasons   []string        `json:"reasons"`
+	PaymentID string          `json:"payment_id"`
+}
+
+// ReviewDecision представляет собой решение ручной проверки платежа
+type ReviewDecision string
+
+const (
+	ReviewApproved ReviewDecision = "APPROVED"
+	ReviewDeclined ReviewDecision = "DECLINED"
+)
+
+// Review представляет результат ручной проверки платежа: кто, когда и почему принял решение
+type Review struct {
+	PaymentID string         `json:"payment_id"`
+	Reviewer  string         `json:"reviewer"`
+	Decision  ReviewDecision `json:"decision"`
+	Reason    string         `json:"reason"`
+	Time      time.Time      `json:"time"`
+}
+
+// Deposit представляет информацию о пополнении счёта
+type Deposit struct {
+	ID        string    `json:"id"`
+	Seq       int64     `json:"seq"`
+	AccountID int64     `json:"account_id"`
+	Amount    Money     `json:"amount"`
+	Time      time.Time `json:"time"`
+}
+
+// Adjustment - корректирующая запись, объясняющая расхождение баланса с историей операций.
+// Amount может быть отрицательным.
+type Adjustment struct {
+	ID        string    `json:"id"`
+	AccountID int64     `json:"account_id"`
+	Amount    Money     `json:"amount"`
+	Reason    string    `json:"reason"`
+	Time      time.Time `json:"time"`
+}
+
+// Rejection представляет отмену платежа: Amount - сумма, вернувшаяся на счёт плательщика
+// вместе с возвращённой комиссией
+type Rejection struct {
+	PaymentID string    `json:"payment_id"`
+	AccountID int64     `json:"account_id"`
+	Amount    Money     `json:"amount"`
+	Time      time.Time `json:"time"`
 }
 
 type Phone string
 
+// AccountStatus представляет собой состояние счёта
+type AccountStatus string
+
+const (
+	AccountStatusActive AccountStatus = "ACTIVE"
+	AccountStatusFrozen AccountStatus = "FROZEN"
+	AccountStatusClosed AccountStatus = "CLOSED"
+)
+
 type Account struct {
-	ID      int64
-	Phone   Phone
-	Balance Money
+	ID      int64         `json:"id"`
+	Phone   Phone         `json:"phone"`
+	Balance Money         `json:"balance"`
+	Status  AccountStatus `json:"status"`
+}
+
+// IdentifierKind представляет собой вид дополнительного идентификатора счёта
+type IdentifierKind string
+
+const (
+	IdentifierEmail IdentifierKind = "EMAIL"
+	IdentifierAlias IdentifierKind = "ALIAS"
+)
+
+// Identifier представляет дополнительный идентификатор счёта, по которому можно сделать перевод
+type Identifier struct {
+	AccountID int64          `json:"account_id"`
+	Kind      IdentifierKind `json:"kind"`
+	Value     string         `json:"value"`
+}
+
+// PhoneChange представляет запись о смене номера телефона
+type PhoneChange struct {
+	AccountID int64     `json:"account_id"`
+	OldPhone  Phone     `json:"old_phone"`
+	NewPhone  Phone     `json:"new_phone"`
+	Time      time.Time `json:"time"`
+}
+
+// Transfer представляет перевод: платёж отправителя, зачисленный на счёт получателя
+type Transfer struct {
+	PaymentID   string `json:"payment_id"`
+	ToAccountID int64  `json:"to_account_id"`
 }
 
 type Favorite struct {
-	ID        string
-	AccountID int64
-	Name      string
-	Amount    Money
-	Category  PaymentCategory
+	ID        string          `json:"id"`
+	Seq       int64           `json:"seq"`
+	Changed   int64           `json:"changed,omitempty"` // номер последнего изменения или удаления
+	AccountID int64           `json:"account_id"`
+	Name      string          `json:"name"`
+	Amount    Money           `json:"amount"`
+	Category  PaymentCategory `json:"category"`
+}
+
+// ScheduleInterval представляет собой периодичность регулярного платежа
+type ScheduleInterval string
+
+const (
+	ScheduleDaily   ScheduleInterval = "DAILY"
+	ScheduleWeekly  ScheduleInterval = "WEEKLY"
+	ScheduleMonthly ScheduleInterval = "MONTHLY"
+	ScheduleCron    ScheduleInterval = "CRON"
+)
+
+// Schedule представляет регулярный платёж по избранному
+type Schedule struct {
+	ID         string           `json:"id"`
+	FavoriteID string           `json:"favorite_id"`
+	Interval   ScheduleInterval `json:"interval"`
+	Cron       string           `json:"cron"` // выражение вида "0 9 * * 1" для ScheduleCron
+	NextRun    time.Time        `json:"next_run"`
+	Anchor     time.Time        `json:"anchor"`   // первый запуск: ежемесячные запуски приходятся на его день
+	RetryAt    time.Time        `json:"retry_at"` // время повторной попытки, если платёж не прошёл
+	Attempts   int              `json:"attempts"`
+	Active     bool             `json:"active"`
+}
+
+// ScheduleRunStatus представляет собой результат запуска регулярного платежа
+type ScheduleRunStatus string
+
+const (
+	ScheduleRunOk    ScheduleRunStatus = "OK"
+	ScheduleRunRetry ScheduleRunStatus = "RETRY"
+	ScheduleRunFail  ScheduleRunStatus = "FAIL"
+)
+
+// ScheduleRun представляет информацию об одном запуске регулярного платежа
+type ScheduleRun struct {
+	ScheduleID string            `json:"schedule_id"`
+	PaymentID  string            `json:"payment_id"`
+	Time       time.Time         `json:"time"`
+	Status     ScheduleRunStatus `json:"status"`
+	Error      string            `json:"error"`
 }
 
 type Progress struct {
-	Part   int
-	Result Money
+	Part   int   `json:"part"`
+	Result Money `json:"result"`
 }
@@ -0,0 +1,219 @@
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrAuditDisabled = errors.New("audit log is not enabled")
+var ErrAuditSeqNotFound = errors.New("audit entry not found")
+
+// BalanceAt восстанавливает баланс счёта на момент at: из текущего баланса вычитаются
+// все движения позже at, кроме корректировок. Записи без времени из старых дампов считаются более ранними.
+func (s *Service) BalanceAt(accountID int64, at time.Time) (types.Money, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return 0, err
+	}
+	return s.balanceAt(account, at), nil
+}
+
+// BalanceAtSeq возвращает баланс счёта сразу после записи журнала аудита seq (0 - до первой записи).
+// Нужен включённый журнал: балансы берутся из записанных в нём изменений.
+func (s *Service) BalanceAtSeq(accountID int64, seq int64) (types.Money, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := s.auditEntriesTo(seq)
+	if err != nil {
+		return 0, err
+	}
+
+	balance := account.Balance
+	for i := len(entries) - 1; i >= 0 && entries[i].Seq > seq; i-- {
+		for _, change := range entries[i].Balances {
+			if change.AccountID == account.ID {
+				balance = change.Before
+			}
+		}
+	}
+	return balance, nil
+}
+
+// StateAt восстанавливает состояние сервиса на момент at в отдельный сервис, который можно
+// выгрузить обычным Export. Счета получают баланс BalanceAt; платежи, пополнения, возвраты,
+// комиссии, отмены, корректировки, проверки и события риска после at отбрасываются,
+// а платежи, отменённые или одобренные позже, возвращаются в прежний статус, номера телефонов - в прежний номер.
+// Записи без времени (счета, идентификаторы, избранное, категории) переносятся как есть;
+// мерчанты, кэшбэк и регулярные платежи не восстанавливаются.
+func (s *Service) StateAt(at time.Time) *Service {
+	past := &Service{
+		nextAccountID: s.nextAccountID,
+		seq:           s.seq,
+		phoneRules:    s.phoneRules,
+		now:           func() time.Time { return at },
+	}
+
+	for _, account := range s.accounts {
+		copied := *account
+		copied.Balance = s.balanceAt(account, at)
+		// номер, сменённый позже at, возвращается к прежнему
+		for i := len(s.phoneChanges) - 1; i >= 0; i-- {
+			change := s.phoneChanges[i]
+			if change.AccountID == account.ID && change.Time.After(at) {
+				copied.Phone = change.OldPhone
+			}
+		}
+		past.accounts = append(past.accounts, &copied)
+	}
+	for _, change := range s.phoneChanges {
+		if !change.Time.After(at) {
+			copied := *change
+			past.phoneChanges = append(past.phoneChanges, &copied)
+		}
+	}
+	for _, identifier := range s.identifiers {
+		copied := *identifier
+		past.identifiers = append(past.identifiers, &copied)
+	}
+	for _, deposit := range s.deposits {
+		if !deposit.Time.After(at) {
+			copied := *deposit
+			past.deposits = append(past.deposits, &copied)
+		}
+	}
+
+	for _, payment := range s.payments {
+		if payment.Time.After(at) {
+			continue
+		}
+		copied := *payment
+		rejectedLater := payment.Status == types.PaymentStatusFail && s.rejectionTime(payment.ID).After(at)
+		if rejectedLater {
+			copied.Status = types.PaymentStatusInProgress
+		}
+		for _, review := range s.reviews {
+			if review.PaymentID == payment.ID && review.Time.After(at) {
+				copied.Status = types.PaymentStatusReview
+			}
+		}
+		past.payments = append(past.payments, &copied)
+
+		if fee := s.feeFor(payment.ID); fee != nil {
+			copiedFee := *fee
+			// комиссия, возвращённая возвратами и отменой позже at, снова считается удержанной
+			rejectedFee := fee.Refunded - s.refundedFee(payment.ID)
+			copiedFee.Refunded = 0
+			for _, refund := range s.refunds {
+				if refund.PaymentID == payment.ID && !refund.Time.After(at) {
+					copiedFee.Refunded += refund.Fee
+				}
+			}
+			if !rejectedLater {
+				copiedFee.Refunded += rejectedFee
+			}
+			past.fees = append(past.fees, &copiedFee)
+		}
+		for _, transfer := range s.transfers {
+			if transfer.PaymentID == payment.ID {
+				copiedTransfer := *transfer
+				past.transfers = append(past.transfers, &copiedTransfer)
+			}
+		}
+	}
+
+	for _, refund := range s.refunds {
+		if !refund.Time.After(at) {
+			copied := *refund
+			past.refunds = append(past.refunds, &copied)
+		}
+	}
+	for _, rejection := range s.rejections {
+		if !rejection.Time.After(at) {
+			copied := *rejection
+			past.rejections = append(past.rejections, &copied)
+		}
+	}
+	for _, adjustment := range s.adjustments {
+		if !adjustment.Time.After(at) {
+			copied := *adjustment
+			past.adjustments = append(past.adjustments, &copied)
+		}
+	}
+	for _, review := range s.reviews {
+		if !review.Time.After(at) {
+			copied := *review
+			past.reviews = append(past.reviews, &copied)
+		}
+	}
+	for _, event := range s.riskEvents {
+		if !event.Time.After(at) {
+			copied := *event
+			past.riskEvents = append(past.riskEvents, &copied)
+		}
+	}
+	for _, favorite := range s.favorites {
+		copied := *favorite
+		past.favorites = append(past.favorites, &copied)
+	}
+	for _, category := range s.categories {
+		copied := *category
+		past.categories = append(past.categories, &copied)
+	}
+	return past
+}
+
+// StateAtSeq восстанавливает состояние на момент записи журнала аудита seq.
+// Записи отбираются по времени этой записи, балансы счетов берутся из журнала.
+// Журнал пишет время по time.Now и не учитывает SetClock: если часы сервиса подменены,
+// время записи журнала и время записей сервиса несравнимы и отбор записей будет неверным.
+func (s *Service) StateAtSeq(seq int64) (*Service, error) {
+	entries, err := s.auditEntriesTo(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Time{}
+	if seq > 0 {
+		at = entries[seq-1].Time
+	}
+	past := s.StateAt(at)
+	for _, account := range past.accounts {
+		account.Balance, err = s.BalanceAtSeq(account.ID, seq)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return past, nil
+}
+
+// ExportAt выгружает в dir состояние на момент at в формате Export
+func (s *Service) ExportAt(dir string, at time.Time) error {
+	return s.StateAt(at).Export(dir)
+}
+
+func (s *Service) balanceAt(account *types.Account, at time.Time) types.Money {
+	balance := account.Balance
+	for _, record := range s.balanceRecords(account.ID) {
+		// AdjustDiscrepancies не меняет баланс, поэтому и вычитать корректировку нечего
+		if record.Kind != RecordAdjustment && record.Time.After(at) {
+			balance -= record.Amount
+		}
+	}
+	return balance
+}
+
+// auditEntriesTo возвращает записи журнала и проверяет, что запись seq в нём есть
+func (s *Service) auditEntriesTo(seq int64) ([]AuditEntry, error) {
+	if s.audit == nil {
+		return nil, ErrAuditDisabled
+	}
+	entries := s.audit.Entries()
+	if seq < 0 || seq > int64(len(entries)) {
+		return nil, ErrAuditSeqNotFound
+	}
+	return entries, nil
+}
@@ -0,0 +1,115 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func TestService_StateAt_success(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2024, 2, 20, 10, 0, 0, 0, time.UTC)}
+	s.SetClock(clock.Now)
+	audit := NewAuditLog(nil)
+	s.EnableAudit(audit)
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 10_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	payment, err := s.Pay(account.ID, 3_000, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	clock.now = march.Add(24 * time.Hour)
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 500, "taxi")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	balance, err := s.BalanceAt(account.ID, march)
+	if err != nil || balance != 7_000 {
+		t.Errorf("BalanceAt(): expected 7000, actual: %d, error = %v", balance, err)
+		return
+	}
+	balance, err = s.BalanceAtSeq(account.ID, 2)
+	if err != nil || balance != 10_000 {
+		t.Errorf("BalanceAtSeq(): expected 10000, actual: %d, error = %v", balance, err)
+		return
+	}
+	_, err = s.BalanceAtSeq(account.ID, 6)
+	if err != ErrAuditSeqNotFound {
+		t.Errorf("BalanceAtSeq(): wrong error = %v", err)
+		return
+	}
+
+	past := s.StateAt(march)
+	if len(past.payments) != 1 || past.payments[0].Status != types.PaymentStatusInProgress || len(past.rejections) != 0 {
+		t.Errorf("StateAt(): wrong payments = %v", past.payments)
+		return
+	}
+	if discrepancies := past.Reconcile(); len(discrepancies) != 0 {
+		t.Errorf("StateAt(): state does not reconcile = %v", discrepancies)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.ExportAt(dir, march)
+	if err != nil {
+		t.Errorf("ExportAt(): error = %v", err)
+		return
+	}
+	imported := &Service{}
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	if diff := past.Diff(imported); !diff.Empty() {
+		t.Errorf("ExportAt(): exported state differs = %v", diff)
+		return
+	}
+	if payment.Status != types.PaymentStatusFail || account.Balance != 9_500 {
+		t.Errorf("StateAt(): current state changed, payment = %v, account = %v", payment, account)
+	}
+}
+
+func TestService_BalanceAt_adjustment(t *testing.T) {
+	s := newTestService()
+	t0 := time.Date(2024, 2, 20, 10, 0, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return t0 })
+
+	// баланс без истории, как у счёта из старого дампа
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	account.Balance = 1_000
+	_, err = s.AdjustDiscrepancies(s.Reconcile(), "legacy balance")
+	if err != nil {
+		t.Errorf("AdjustDiscrepancies(): error = %v", err)
+		return
+	}
+
+	balance, err := s.BalanceAt(account.ID, t0.Add(-time.Hour))
+	if err != nil || balance != 1_000 {
+		t.Errorf("BalanceAt(): balance = %d, error = %v", balance, err)
+	}
+}
@@ -0,0 +1,257 @@
+package wallet
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrAuditTampered = errors.New("audit log tampered")
+
+// BalanceChange - баланс счёта до и после операции
+type BalanceChange struct {
+	AccountID int64       `json:"account_id"`
+	Before    types.Money `json:"before"`
+	After     types.Money `json:"after"`
+}
+
+// AuditEntry - запись журнала аудита. Hash считается от PrevHash и всех остальных полей,
+// поэтому изменение или удаление любой записи ломает цепочку.
+type AuditEntry struct {
+	Seq       int64             `json:"seq"`
+	Time      time.Time         `json:"time"`
+	Actor     string            `json:"actor"`
+	Operation string            `json:"operation"`
+	Args      map[string]string `json:"args"`
+	Balances  []BalanceChange   `json:"balances"`
+	Result    string            `json:"result"`
+	PrevHash  string            `json:"prev_hash"`
+	Hash      string            `json:"hash"`
+}
+
+// AuditResultOk - результат успешной операции, для неудачной записывается текст ошибки
+const AuditResultOk = "ok"
+
+// AuditLog - журнал аудита, в который можно только дописывать.
+// Если задан writer, каждая запись сразу дописывается в него строкой JSON.
+type AuditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+	writer  io.Writer
+	now     func() time.Time
+}
+
+func NewAuditLog(writer io.Writer) *AuditLog {
+	return &AuditLog{
+		writer: writer,
+		now:    time.Now,
+	}
+}
+
+// OpenAuditLog продолжает журнал из файла path: существующие записи проверяются,
+// новые дописываются в конец файла
+func OpenAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ReadAuditLog(file)
+	if err == nil {
+		err = VerifyAudit(entries, "")
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	audit := NewAuditLog(file)
+	audit.entries = entries
+	return audit, nil
+}
+
+// Close закрывает writer журнала, если его можно закрыть
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if closer, ok := l.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Entries возвращает копию всех записей журнала
+func (l *AuditLog) Entries() []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]AuditEntry, len(l.entries))
+	copy(entries, l.entries)
+	return entries
+}
+
+// Head возвращает хеш последней записи. Сохранённый отдельно, он позволяет
+// обнаружить и удаление записей с конца журнала.
+func (l *AuditLog) Head() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) == 0 {
+		return ""
+	}
+	return l.entries[len(l.entries)-1].Hash
+}
+
+func (l *AuditLog) append(entry AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Seq = int64(len(l.entries)) + 1
+	entry.Time = l.now().UTC()
+	if len(l.entries) > 0 {
+		entry.PrevHash = l.entries[len(l.entries)-1].Hash
+	}
+	entry.Hash = hashAuditEntry(entry)
+	l.entries = append(l.entries, entry)
+
+	if l.writer == nil {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = l.writer.Write(append(data, '\n'))
+	return err
+}
+
+// ReadAuditLog читает записи, сохранённые AuditLog построчно в JSON
+func ReadAuditLog(reader io.Reader) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// VerifyAudit проверяет цепочку хешей и непрерывность номеров записей.
+// Если head не пуст, последняя запись должна иметь этот хеш.
+func VerifyAudit(entries []AuditEntry, head string) error {
+	prev := ""
+	for i, entry := range entries {
+		if entry.Seq != int64(i)+1 {
+			return fmt.Errorf("%w: entry %d has seq %d", ErrAuditTampered, i+1, entry.Seq)
+		}
+		if entry.PrevHash != prev {
+			return fmt.Errorf("%w: entry %d is not linked to previous", ErrAuditTampered, entry.Seq)
+		}
+		if hashAuditEntry(entry) != entry.Hash {
+			return fmt.Errorf("%w: entry %d was modified", ErrAuditTampered, entry.Seq)
+		}
+		prev = entry.Hash
+	}
+	if head != "" && prev != head {
+		return fmt.Errorf("%w: log does not end with head %s", ErrAuditTampered, head)
+	}
+	return nil
+}
+
+func hashAuditEntry(entry AuditEntry) string {
+	entry.Hash = ""
+	// json.Marshal сортирует ключи map, поэтому сериализация однозначна
+	data, _ := json.Marshal(entry)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// EnableAudit включает запись всех изменяющих операций сервиса в журнал
+func (s *Service) EnableAudit(audit *AuditLog) {
+	s.audit = audit
+}
+
+// SetActor задаёт, от чьего имени выполняются следующие операции (пользователь, сервис, оператор)
+func (s *Service) SetActor(actor string) {
+	s.actor = actor
+}
+
+// audited начинает запись операции в журнал; возвращённую функцию нужно вызвать через defer
+// с указателем на ошибку операции. Вложенные вызовы (Repeat -> Pay) пишутся одной записью.
+func (s *Service) audited(operation string, args map[string]string) func(*error) {
+	if s.audit == nil {
+		return func(*error) {}
+	}
+
+	s.auditDepth++
+	if s.auditDepth > 1 {
+		return func(*error) { s.auditDepth-- }
+	}
+
+	before := s.balances()
+	return func(errp *error) {
+		s.auditDepth--
+
+		result := AuditResultOk
+		if *errp != nil {
+			result = (*errp).Error()
+		}
+
+		after := s.balances()
+		var changes []BalanceChange
+		for id, balance := range after {
+			if before[id] != balance {
+				changes = append(changes, BalanceChange{AccountID: id, Before: before[id], After: balance})
+			}
+		}
+		sort.Slice(changes, func(i, j int) bool { return changes[i].AccountID < changes[j].AccountID })
+
+		// операция уже выполнена, поэтому ошибку записи не возвращаем, а только логируем
+		err := s.audit.append(AuditEntry{
+			Actor:     s.actor,
+			Operation: operation,
+			Args:      args,
+			Balances:  changes,
+			Result:    result,
+		})
+		if err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+func (s *Service) balances() map[int64]types.Money {
+	balances := make(map[int64]types.Money, len(s.accounts))
+	for _, account := range s.accounts {
+		balances[account.ID] = account.Balance
+	}
+	return balances
+}
+
+func auditArgs(kv ...interface{}) map[string]string {
+	args := make(map[string]string, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		args[fmt.Sprint(kv[i])] = fmt.Sprint(kv[i+1])
+	}
+	return args
+}
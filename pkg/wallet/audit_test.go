@@ -0,0 +1,123 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestService_audit_success(t *testing.T) {
+	s := newTestService()
+	var buf bytes.Buffer
+	audit := NewAuditLog(&buf)
+	s.EnableAudit(audit)
+	s.SetActor("operator")
+
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	err = s.Reject(payments[0].ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, -1)
+	if err != ErrAmountMustBePositive {
+		t.Errorf("Deposit(): wrong error = %v", err)
+		return
+	}
+
+	entries := audit.Entries()
+	// регистрация, пополнение, платёж, отмена и неудачное пополнение
+	if len(entries) != 5 {
+		t.Errorf("Entries(): expected 5 entries, actual: %v", entries)
+		return
+	}
+	reject := entries[3]
+	if reject.Operation != "Reject" || reject.Actor != "operator" || reject.Result != AuditResultOk {
+		t.Errorf("Entries(): wrong reject entry = %v", reject)
+		return
+	}
+	if len(reject.Balances) != 1 || reject.Balances[0].After-reject.Balances[0].Before != payments[0].Amount {
+		t.Errorf("Entries(): wrong balances = %v", reject.Balances)
+		return
+	}
+	if entries[4].Result != ErrAmountMustBePositive.Error() || len(entries[4].Balances) != 0 {
+		t.Errorf("Entries(): wrong failed entry = %v", entries[4])
+		return
+	}
+
+	read, err := ReadAuditLog(&buf)
+	if err != nil {
+		t.Errorf("ReadAuditLog(): error = %v", err)
+		return
+	}
+	err = VerifyAudit(read, audit.Head())
+	if err != nil {
+		t.Errorf("VerifyAudit(): error = %v", err)
+	}
+}
+
+func TestVerifyAudit_tampered(t *testing.T) {
+	s := newTestService()
+	audit := NewAuditLog(nil)
+	s.EnableAudit(audit)
+
+	_, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+
+	modified := audit.Entries()
+	modified[1].Balances[0].After += 1_000_000
+	err = VerifyAudit(modified, audit.Head())
+	if !errors.Is(err, ErrAuditTampered) {
+		t.Errorf("VerifyAudit(): modified entry not detected, error = %v", err)
+	}
+
+	entries := audit.Entries()
+	deleted := append(entries[:1:1], entries[2:]...)
+	err = VerifyAudit(deleted, audit.Head())
+	if !errors.Is(err, ErrAuditTampered) {
+		t.Errorf("VerifyAudit(): deleted entry not detected, error = %v", err)
+	}
+
+	entries = audit.Entries()
+	err = VerifyAudit(entries[:len(entries)-1], audit.Head())
+	if !errors.Is(err, ErrAuditTampered) {
+		t.Errorf("VerifyAudit(): deleted last entry not detected, error = %v", err)
+	}
+}
+
+func TestOpenAuditLog_continue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for i := 0; i < 2; i++ {
+		audit, err := OpenAuditLog(path)
+		if err != nil {
+			t.Errorf("OpenAuditLog(): error = %v", err)
+			return
+		}
+		s := newTestService()
+		s.EnableAudit(audit)
+		_, err = s.RegisterAccount("+992000000001")
+		if err != nil {
+			t.Errorf("RegisterAccount(): error = %v", err)
+			return
+		}
+		audit.Close()
+	}
+
+	audit, err := OpenAuditLog(path)
+	if err != nil {
+		t.Errorf("OpenAuditLog(): error = %v", err)
+		return
+	}
+	defer audit.Close()
+	if entries := audit.Entries(); len(entries) != 2 || entries[1].Seq != 2 {
+		t.Errorf("OpenAuditLog(): expected continued chain, actual: %v", entries)
+	}
+}
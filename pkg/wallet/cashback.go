@@ -0,0 +1,139 @@
+package wallet
+
+import (
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+// CashbackRule - процент кэшбэка за платежи в категории
+type CashbackRule struct {
+	Category    types.PaymentCategory
+	BasisPoints int64 // 100 = 1%
+}
+
+// CashbackProgram - программа лояльности: кэшбэк начисляется только в категориях из Rules,
+// за календарный месяц на счёт начисляется не больше MonthlyCap (0 - без ограничения)
+type CashbackProgram struct {
+	Rules      []CashbackRule
+	MonthlyCap types.Money
+}
+
+// SetCashbackProgram задаёт программу кэшбэка для новых платежей
+func (s *Service) SetCashbackProgram(program CashbackProgram) {
+	s.cashback = program
+}
+
+// RewardsBalance возвращает подтверждённый кэшбэк счёта и кэшбэк, ждущий подтверждения платежей
+func (s *Service) RewardsBalance(accountID int64) (confirmed types.Money, pending types.Money, err error) {
+	if _, err := s.FindAccountByID(accountID); err != nil {
+		return 0, 0, err
+	}
+
+	for _, cashback := range s.cashbacks {
+		if cashback.AccountID != accountID {
+			continue
+		}
+		switch cashback.Status {
+		case types.CashbackConfirmed:
+			confirmed += cashback.Amount - cashback.Refunded
+		case types.CashbackPending:
+			pending += cashback.Amount - cashback.Refunded
+		}
+	}
+	return confirmed, pending, nil
+}
+
+// RewardsHistory возвращает все начисления кэшбэка по счёту, включая отменённые
+func (s *Service) RewardsHistory(accountID int64) ([]types.Cashback, error) {
+	if _, err := s.FindAccountByID(accountID); err != nil {
+		return nil, err
+	}
+
+	var history []types.Cashback
+	for _, cashback := range s.cashbacks {
+		if cashback.AccountID == accountID {
+			history = append(history, *cashback)
+		}
+	}
+	return history, nil
+}
+
+func (s *Service) accrueCashback(payment *types.Payment) error {
+	var rule *CashbackRule
+	for i := range s.cashback.Rules {
+		if s.cashback.Rules[i].Category == payment.Category {
+			rule = &s.cashback.Rules[i]
+			break
+		}
+	}
+	if rule == nil {
+		return nil
+	}
+
+	now := s.clock()
+	amount := types.Money(int64(payment.Amount) * rule.BasisPoints / 10000)
+	if s.cashback.MonthlyCap > 0 {
+		left := s.cashback.MonthlyCap - s.monthCashback(payment.AccountID, now)
+		if amount > left {
+			amount = left
+		}
+	}
+	if amount <= 0 {
+		return nil
+	}
+
+	id, err := s.newID()
+	if err != nil {
+		return err
+	}
+	cashback := &types.Cashback{
+		ID:        id,
+		PaymentID: payment.ID,
+		AccountID: payment.AccountID,
+		Amount:    amount,
+		Status:    types.CashbackPending,
+		Time:      now,
+	}
+	s.cashbacks = append(s.cashbacks, cashback)
+	s.publish(CashbackAccrued{Cashback: *cashback})
+	return nil
+}
+
+// monthCashback возвращает кэшбэк счёта, не отменённый и начисленный в том же месяце, что и now
+func (s *Service) monthCashback(accountID int64, now time.Time) types.Money {
+	year, month, _ := now.Date()
+	sum := types.Money(0)
+	for _, cashback := range s.cashbacks {
+		if cashback.AccountID != accountID || cashback.Status == types.CashbackReversed {
+			continue
+		}
+		y, m, _ := cashback.Time.In(now.Location()).Date()
+		if y == year && m == month {
+			sum += cashback.Amount - cashback.Refunded
+		}
+	}
+	return sum
+}
+
+// reduceCashback снимает с кэшбэка за платёж часть, пропорциональную всем возвратам по нему
+func (s *Service) reduceCashback(payment *types.Payment) {
+	if payment.Amount <= 0 {
+		return
+	}
+	refunded := s.refundedAmount(payment.ID)
+	for _, cashback := range s.cashbacks {
+		if cashback.PaymentID == payment.ID && cashback.Status != types.CashbackReversed {
+			cashback.Refunded = types.Money(int64(cashback.Amount) * int64(refunded) / int64(payment.Amount))
+		}
+	}
+}
+
+// setCashbackStatus подтверждает или отменяет кэшбэк за платёж вслед за статусом платежа
+func (s *Service) setCashbackStatus(paymentID string, status types.CashbackStatus) {
+	for _, cashback := range s.cashbacks {
+		if cashback.PaymentID == paymentID && cashback.Status != types.CashbackReversed {
+			cashback.Status = status
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func TestService_cashback_success(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)}
+	s.SetClock(clock.Now)
+	s.SetCashbackProgram(CashbackProgram{
+		Rules:      []CashbackRule{{Category: "auto", BasisPoints: 500}},
+		MonthlyCap: 700,
+	})
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 100_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+
+	first, _ := s.Pay(account.ID, 10_000, "auto")
+	second, _ := s.Pay(account.ID, 10_000, "auto")
+	s.Pay(account.ID, 10_000, "shop")
+
+	confirmed, pending, err := s.RewardsBalance(account.ID)
+	if err != nil {
+		t.Errorf("RewardsBalance(): error = %v", err)
+		return
+	}
+	// второй платёж упирается в месячный лимит
+	if confirmed != 0 || pending != 700 {
+		t.Errorf("RewardsBalance(): expected pending 700, actual: %d, %d", confirmed, pending)
+		return
+	}
+
+	err = s.CompletePayment(first.ID)
+	if err != nil {
+		t.Errorf("CompletePayment(): error = %v", err)
+		return
+	}
+	err = s.Reject(second.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	confirmed, pending, _ = s.RewardsBalance(account.ID)
+	if confirmed != 500 || pending != 0 {
+		t.Errorf("RewardsBalance(): expected confirmed 500, actual: %d, %d", confirmed, pending)
+		return
+	}
+
+	// в новом месяце лимит начинается заново
+	clock.now = clock.now.AddDate(0, 0, 1)
+	s.Pay(account.ID, 20_000, "auto")
+	history, err := s.RewardsHistory(account.ID)
+	if err != nil {
+		t.Errorf("RewardsHistory(): error = %v", err)
+		return
+	}
+	if len(history) != 3 || history[1].Status != types.CashbackReversed || history[2].Amount != 700 {
+		t.Errorf("RewardsHistory(): wrong history = %v", history)
+	}
+}
+
+func TestService_Refund_cashback(t *testing.T) {
+	s := newTestService()
+	s.SetCashbackProgram(CashbackProgram{Rules: []CashbackRule{{Category: "auto", BasisPoints: 500}}})
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 100_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	payment, err := s.Pay(account.ID, 10_000, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 4_000)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+	_, pending, _ := s.RewardsBalance(account.ID)
+	if pending != 300 {
+		t.Errorf("RewardsBalance(): expected pending 300 after partial refund, actual: %d", pending)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 6_000)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+	_, pending, _ = s.RewardsBalance(account.ID)
+	if pending != 0 {
+		t.Errorf("RewardsBalance(): expected no cashback after full refund, actual: %d", pending)
+	}
+}
@@ -0,0 +1,140 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrCategoryNotFound = errors.New("category not found")
+var ErrCategoryExists = errors.New("category already exists")
+var ErrCategoryDisabled = errors.New("category is disabled")
+var ErrInvalidCategory = errors.New("invalid category")
+
+// AddCategory добавляет категорию в справочник. Пока справочник пуст, Pay принимает любые категории,
+// после добавления первой - только известные и включённые.
+func (s *Service) AddCategory(category types.Category) (rerr error) {
+	defer s.audited("AddCategory", auditArgs("code", category.Code, "parent", category.Parent, "enabled", category.Enabled))(&rerr)
+
+	if _, err := s.FindCategory(category.Code); err == nil {
+		return ErrCategoryExists
+	}
+	err := s.validateCategory(category)
+	if err != nil {
+		return err
+	}
+
+	s.categories = append(s.categories, &category)
+	s.publish(CategoryAdded{Category: category})
+	return nil
+}
+
+// UpdateCategory меняет название, родителя, признак включения и мерчанта категории
+func (s *Service) UpdateCategory(category types.Category) (rerr error) {
+	defer s.audited("UpdateCategory", auditArgs("code", category.Code, "parent", category.Parent, "enabled", category.Enabled))(&rerr)
+
+	current, err := s.FindCategory(category.Code)
+	if err != nil {
+		return err
+	}
+	err = s.validateCategory(category)
+	if err != nil {
+		return err
+	}
+
+	*current = category
+	s.publish(CategoryUpdated{Category: category})
+	return nil
+}
+
+func (s *Service) FindCategory(code types.PaymentCategory) (*types.Category, error) {
+	for _, category := range s.categories {
+		if category.Code == code {
+			return category, nil
+		}
+	}
+
+	return nil, ErrCategoryNotFound
+}
+
+// Categories возвращает копию справочника категорий
+func (s *Service) Categories() []types.Category {
+	categories := make([]types.Category, 0, len(s.categories))
+	for _, category := range s.categories {
+		categories = append(categories, *category)
+	}
+	return categories
+}
+
+// RootCategory возвращает категорию верхнего уровня, к которой относится code.
+// Категории не из справочника возвращаются как есть.
+func (s *Service) RootCategory(code types.PaymentCategory) types.PaymentCategory {
+	for i := 0; i <= len(s.categories); i++ {
+		category, err := s.FindCategory(code)
+		if err != nil || category.Parent == "" {
+			return code
+		}
+		code = category.Parent
+	}
+	return code
+}
+
+// SumPaymentsByCategory суммирует платежи по категориям верхнего уровня
+func (s *Service) SumPaymentsByCategory() map[types.PaymentCategory]types.Money {
+	sums := make(map[types.PaymentCategory]types.Money)
+	for _, payment := range s.payments {
+		sums[s.RootCategory(payment.Category)] += payment.Amount
+	}
+	return sums
+}
+
+// checkCategory проверяет, что в категорию можно платить: она есть в справочнике
+// и включена вместе со всеми родителями
+func (s *Service) checkCategory(code types.PaymentCategory) error {
+	if len(s.categories) == 0 {
+		return nil
+	}
+
+	for i := 0; i <= len(s.categories); i++ {
+		category, err := s.FindCategory(code)
+		if err != nil {
+			return err
+		}
+		if !category.Enabled {
+			return ErrCategoryDisabled
+		}
+		if category.Parent == "" {
+			return nil
+		}
+		code = category.Parent
+	}
+	return ErrInvalidCategory
+}
+
+func (s *Service) validateCategory(category types.Category) error {
+	if category.Code == "" || strings.ContainsAny(string(category.Code), ";\n") ||
+		strings.ContainsAny(category.Name, ";\n") || strings.ContainsAny(category.Merchant, ";\n") {
+		return ErrInvalidCategory
+	}
+	if category.Parent == "" {
+		return nil
+	}
+
+	// родитель должен существовать, и категория не может оказаться своим же предком
+	code := category.Parent
+	for i := 0; i <= len(s.categories); i++ {
+		if code == category.Code {
+			return ErrInvalidCategory
+		}
+		parent, err := s.FindCategory(code)
+		if err != nil {
+			return err
+		}
+		if parent.Parent == "" {
+			return nil
+		}
+		code = parent.Parent
+	}
+	return ErrInvalidCategory
+}
@@ -0,0 +1,87 @@
+package wallet
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func TestService_Pay_category(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+
+	categories := []types.Category{
+		{Code: "food", Name: "Еда", Enabled: true},
+		{Code: "coffee", Name: "Кофейни", Parent: "food", Enabled: true},
+		{Code: "shop", Name: "Магазины", Enabled: false},
+	}
+	for _, category := range categories {
+		err = s.AddCategory(category)
+		if err != nil {
+			t.Errorf("AddCategory(): error = %v", err)
+			return
+		}
+	}
+
+	_, err = s.Pay(account.ID, 100, "coffe")
+	if err != ErrCategoryNotFound {
+		t.Errorf("Pay(): wrong error for unknown category = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 100, "shop")
+	if err != ErrCategoryDisabled {
+		t.Errorf("Pay(): wrong error for disabled category = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 100, "coffee")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	// выключенный родитель выключает и дочерние категории
+	err = s.UpdateCategory(types.Category{Code: "food", Name: "Еда", Enabled: false})
+	if err != nil {
+		t.Errorf("UpdateCategory(): error = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 100, "coffee")
+	if err != ErrCategoryDisabled {
+		t.Errorf("Pay(): wrong error for disabled parent = %v", err)
+		return
+	}
+
+	err = s.UpdateCategory(types.Category{Code: "food", Parent: "coffee", Enabled: true})
+	if err != ErrInvalidCategory {
+		t.Errorf("UpdateCategory(): cycle not rejected, error = %v", err)
+		return
+	}
+
+	sums := s.SumPaymentsByCategory()
+	want := map[types.PaymentCategory]types.Money{"auto": 10_000, "food": 100}
+	if !reflect.DeepEqual(want, sums) {
+		t.Errorf("SumPaymentsByCategory(): expected: %v, actual: %v", want, sums)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := &Service{}
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(s.Categories(), imported.Categories()) {
+		t.Errorf("Import(): expected categories: %v, actual: %v", s.Categories(), imported.Categories())
+	}
+}
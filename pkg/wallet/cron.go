@@ -0,0 +1,136 @@
+package wallet
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+// cronSpec - разобранное выражение из пяти полей: минута, час, день месяца, месяц, день недели
+type cronSpec struct {
+	minutes  map[int]bool
+	hours    map[int]bool
+	days     map[int]bool
+	months   map[int]bool
+	weekdays map[int]bool
+	anyDay   bool
+	anyWeek  bool
+}
+
+func parseCron(expr string) (*cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, ErrInvalidCron
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	sets := make([]map[int]bool, 5)
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	return &cronSpec{
+		minutes:  sets[0],
+		hours:    sets[1],
+		days:     sets[2],
+		months:   sets[3],
+		weekdays: sets[4],
+		anyDay:   fields[2] == "*",
+		anyWeek:  fields[4] == "*",
+	}, nil
+}
+
+// parseCronField разбирает поле вида "*", "5", "1-5", "*/15", "1,15,30"
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, ErrInvalidCron
+			}
+			step = n
+			part = part[:i]
+		}
+
+		from, to := min, max
+		if part != "*" {
+			if i := strings.Index(part, "-"); i >= 0 {
+				a, err := strconv.Atoi(part[:i])
+				if err != nil {
+					return nil, ErrInvalidCron
+				}
+				b, err := strconv.Atoi(part[i+1:])
+				if err != nil {
+					return nil, ErrInvalidCron
+				}
+				from, to = a, b
+			} else {
+				n, err := strconv.Atoi(part)
+				if err != nil {
+					return nil, ErrInvalidCron
+				}
+				from, to = n, n
+			}
+		}
+		if from < min || to > max || from > to {
+			return nil, ErrInvalidCron
+		}
+
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// matchDay, как и в обычном cron, при заданных днях месяца и недели достаточно совпадения одного из них
+func (c *cronSpec) matchDay(t time.Time) bool {
+	day := c.days[t.Day()]
+	week := c.weekdays[int(t.Weekday())]
+	switch {
+	case c.anyDay && c.anyWeek:
+		return true
+	case c.anyDay:
+		return week
+	case c.anyWeek:
+		return day
+	default:
+		return day || week
+	}
+}
+
+// next возвращает первое подходящее время строго после after
+func (c *cronSpec) next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// за пять лет любое корректное выражение (например, 29 февраля) должно совпасть
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !c.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,273 @@
+package wallet
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+// EventType представляет собой вид изменения состояния сервиса
+type EventType string
+
+const (
+	EventAccountRegistered    EventType = "ACCOUNT_REGISTERED"
+	EventAccountStatusChanged EventType = "ACCOUNT_STATUS_CHANGED"
+	EventPhoneChanged         EventType = "PHONE_CHANGED"
+	EventDeposited            EventType = "DEPOSITED"
+	EventPaymentCreated       EventType = "PAYMENT_CREATED"
+	EventPaymentCompleted     EventType = "PAYMENT_COMPLETED"
+	EventPaymentRejected      EventType = "PAYMENT_REJECTED"
+	EventPaymentRefunded      EventType = "PAYMENT_REFUNDED"
+	EventTransferred          EventType = "TRANSFERRED"
+	EventFavoriteCreated      EventType = "FAVORITE_CREATED"
+	EventFavoriteUpdated      EventType = "FAVORITE_UPDATED"
+	EventFavoriteDeleted      EventType = "FAVORITE_DELETED"
+	EventImported             EventType = "IMPORTED"
+	EventMerged               EventType = "MERGED"
+	EventIdentifierAdded      EventType = "IDENTIFIER_ADDED"
+	EventIdentifierRemoved    EventType = "IDENTIFIER_REMOVED"
+	EventScheduleCreated      EventType = "SCHEDULE_CREATED"
+	EventScheduleCanceled     EventType = "SCHEDULE_CANCELED"
+	EventScheduleRan          EventType = "SCHEDULE_RAN"
+	EventMerchantRegistered   EventType = "MERCHANT_REGISTERED"
+	EventCategoryAssigned     EventType = "CATEGORY_ASSIGNED"
+	EventSettled              EventType = "SETTLED"
+	EventCategoryAdded        EventType = "CATEGORY_ADDED"
+	EventCategoryUpdated      EventType = "CATEGORY_UPDATED"
+	EventCashbackAccrued      EventType = "CASHBACK_ACCRUED"
+	EventAccountAdjusted      EventType = "ACCOUNT_ADJUSTED"
+)
+
+// Event - доменное событие. Конкретный тип события можно получить через type switch.
+type Event interface {
+	Type() EventType
+}
+
+type AccountRegistered struct {
+	Account types.Account
+}
+
+type AccountStatusChanged struct {
+	AccountID int64
+	From      types.AccountStatus
+	To        types.AccountStatus
+}
+
+type PhoneChanged struct {
+	Change types.PhoneChange
+}
+
+type Deposited struct {
+	AccountID int64
+	Amount    types.Money
+	Balance   types.Money
+}
+
+type PaymentCreated struct {
+	Payment types.Payment
+}
+
+type PaymentCompleted struct {
+	Payment types.Payment
+}
+
+// PaymentRejected содержит сумму, фактически вернувшуюся на счёт (без учёта ранних возвратов)
+type PaymentRejected struct {
+	Payment types.Payment
+	Amount  types.Money
+}
+
+type PaymentRefunded struct {
+	Refund types.Refund
+}
+
+type Transferred struct {
+	Payment     types.Payment
+	ToAccountID int64
+}
+
+type FavoriteCreated struct {
+	Favorite types.Favorite
+}
+
+type FavoriteUpdated struct {
+	Favorite types.Favorite
+}
+
+type FavoriteDeleted struct {
+	Favorite types.Favorite
+}
+
+type Imported struct {
+	Dir string
+}
+
+type Merged struct {
+	Dir    string
+	Policy MergePolicy
+}
+
+type IdentifierAdded struct {
+	Identifier types.Identifier
+}
+
+type IdentifierRemoved struct {
+	Identifier types.Identifier
+}
+
+type ScheduleCreated struct {
+	Schedule types.Schedule
+}
+
+type ScheduleCanceled struct {
+	Schedule types.Schedule
+}
+
+// ScheduleRan публикуется планировщиком после каждого запуска, в том числе неудачного
+type ScheduleRan struct {
+	Run types.ScheduleRun
+}
+
+type MerchantRegistered struct {
+	Merchant types.Merchant
+}
+
+type CategoryAssigned struct {
+	MerchantID string
+	Category   types.PaymentCategory
+}
+
+type Settled struct {
+	Settlement Settlement
+}
+
+type CategoryAdded struct {
+	Category types.Category
+}
+
+type CategoryUpdated struct {
+	Category types.Category
+}
+
+type CashbackAccrued struct {
+	Cashback types.Cashback
+}
+
+// AccountAdjusted публикуется для каждой корректировки AdjustDiscrepancies
+type AccountAdjusted struct {
+	Adjustment types.Adjustment
+}
+
+func (AccountRegistered) Type() EventType    { return EventAccountRegistered }
+func (AccountStatusChanged) Type() EventType { return EventAccountStatusChanged }
+func (PhoneChanged) Type() EventType         { return EventPhoneChanged }
+func (Deposited) Type() EventType            { return EventDeposited }
+func (PaymentCreated) Type() EventType       { return EventPaymentCreated }
+func (PaymentCompleted) Type() EventType     { return EventPaymentCompleted }
+func (PaymentRejected) Type() EventType      { return EventPaymentRejected }
+func (PaymentRefunded) Type() EventType      { return EventPaymentRefunded }
+func (Transferred) Type() EventType          { return EventTransferred }
+func (FavoriteCreated) Type() EventType      { return EventFavoriteCreated }
+func (FavoriteUpdated) Type() EventType      { return EventFavoriteUpdated }
+func (FavoriteDeleted) Type() EventType      { return EventFavoriteDeleted }
+func (Imported) Type() EventType             { return EventImported }
+func (Merged) Type() EventType               { return EventMerged }
+func (IdentifierAdded) Type() EventType      { return EventIdentifierAdded }
+func (IdentifierRemoved) Type() EventType    { return EventIdentifierRemoved }
+func (ScheduleCreated) Type() EventType      { return EventScheduleCreated }
+func (ScheduleCanceled) Type() EventType     { return EventScheduleCanceled }
+func (ScheduleRan) Type() EventType          { return EventScheduleRan }
+func (MerchantRegistered) Type() EventType   { return EventMerchantRegistered }
+func (CategoryAssigned) Type() EventType     { return EventCategoryAssigned }
+func (Settled) Type() EventType              { return EventSettled }
+func (CategoryAdded) Type() EventType        { return EventCategoryAdded }
+func (CategoryUpdated) Type() EventType      { return EventCategoryUpdated }
+func (CashbackAccrued) Type() EventType      { return EventCashbackAccrued }
+func (AccountAdjusted) Type() EventType      { return EventAccountAdjusted }
+
+// Subscription - подписка на события сервиса: либо обработчик, либо буферизованный канал
+type Subscription struct {
+	handler func(Event)
+	ch      chan Event
+	dropped int64
+}
+
+// Events возвращает канал подписки, созданной SubscribeChan (для обработчика - nil)
+func (sub *Subscription) Events() <-chan Event {
+	return sub.ch
+}
+
+// Dropped возвращает число событий, не попавших в переполненный канал
+func (sub *Subscription) Dropped() int64 {
+	return atomic.LoadInt64(&sub.dropped)
+}
+
+// eventBus хранит подписчиков; защищён мьютексом, чтобы подписываться можно было из любой горутины
+type eventBus struct {
+	mu   sync.Mutex
+	subs []*Subscription
+}
+
+// Subscribe вызывает handler синхронно после каждого изменения состояния,
+// поэтому handler не должен вызывать методы сервиса
+func (s *Service) Subscribe(handler func(Event)) *Subscription {
+	sub := &Subscription{handler: handler}
+	s.events.add(sub)
+	return sub
+}
+
+// SubscribeChan отдаёт события в канал с буфером size. Если читатель не успевает
+// и буфер заполнен, событие отбрасывается и учитывается в Dropped: сервис не ждёт подписчиков.
+func (s *Service) SubscribeChan(size int) *Subscription {
+	sub := &Subscription{ch: make(chan Event, size)}
+	s.events.add(sub)
+	return sub
+}
+
+// Unsubscribe отключает подписку и закрывает её канал
+func (s *Service) Unsubscribe(sub *Subscription) {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+
+	for i, v := range s.events.subs {
+		if v == sub {
+			s.events.subs = append(s.events.subs[:i], s.events.subs[i+1:]...)
+			if sub.ch != nil {
+				close(sub.ch)
+			}
+			return
+		}
+	}
+}
+
+func (b *eventBus) add(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subs = append(b.subs, sub)
+}
+
+func (s *Service) publish(event Event) {
+	var handlers []func(Event)
+
+	// отправка в каналы не блокирует, поэтому её можно делать под мьютексом,
+	// а Unsubscribe не закроет канал посреди отправки
+	s.events.mu.Lock()
+	for _, sub := range s.events.subs {
+		if sub.handler != nil {
+			handlers = append(handlers, sub.handler)
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			atomic.AddInt64(&sub.dropped, 1)
+		}
+	}
+	s.events.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
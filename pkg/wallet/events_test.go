@@ -0,0 +1,131 @@
+package wallet
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func TestService_Subscribe_success(t *testing.T) {
+	s := newTestService()
+	var got []EventType
+	s.Subscribe(func(event Event) {
+		got = append(got, event.Type())
+	})
+
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	_, err = s.FavoritePayment(payments[0].ID, "auto")
+	if err != nil {
+		t.Errorf("FavoritePayment(): error = %v", err)
+		return
+	}
+	err = s.Reject(payments[0].ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	want := []EventType{EventAccountRegistered, EventDeposited, EventPaymentCreated, EventFavoriteCreated, EventPaymentRejected}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Subscribe(): expected: %v, actual: %v", want, got)
+	}
+}
+
+func TestService_SubscribeChan_success(t *testing.T) {
+	s := newTestService()
+	sub := s.SubscribeChan(2)
+
+	account, err := s.addAccountWithBalance("+992926421505", 1000)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	registered, ok := (<-sub.Events()).(AccountRegistered)
+	if !ok || registered.Account.Phone != "+992926421505" {
+		t.Errorf("SubscribeChan(): expected AccountRegistered, actual: %v", registered)
+		return
+	}
+	deposited, ok := (<-sub.Events()).(Deposited)
+	if !ok || deposited.Balance != types.Money(1000) {
+		t.Errorf("SubscribeChan(): expected Deposited, actual: %v", deposited)
+		return
+	}
+	if sub.Dropped() != 1 {
+		t.Errorf("Dropped(): PaymentCreated must be dropped from full buffer, actual: %v", sub.Dropped())
+		return
+	}
+
+	s.Unsubscribe(sub)
+	_, ok = <-sub.Events()
+	if ok {
+		t.Errorf("Unsubscribe(): channel must be closed")
+	}
+}
+
+func TestService_Subscribe_catalog(t *testing.T) {
+	s := newTestService()
+	var got []EventType
+	s.Subscribe(func(event Event) {
+		got = append(got, event.Type())
+	})
+
+	merchant, err := s.RegisterMerchant("Auto Service")
+	if err != nil {
+		t.Errorf("RegisterMerchant(): error = %v", err)
+		return
+	}
+	err = s.AddCategory(types.Category{Code: "auto", Name: "Авто", Enabled: true})
+	if err != nil {
+		t.Errorf("AddCategory(): error = %v", err)
+		return
+	}
+	err = s.UpdateCategory(types.Category{Code: "auto", Name: "Автомобиль", Enabled: true})
+	if err != nil {
+		t.Errorf("UpdateCategory(): error = %v", err)
+		return
+	}
+	err = s.AssignCategory(merchant.ID, "auto")
+	if err != nil {
+		t.Errorf("AssignCategory(): error = %v", err)
+		return
+	}
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	_, err = s.AddIdentifier(account.ID, types.IdentifierAlias, "driver")
+	if err != nil {
+		t.Errorf("AddIdentifier(): error = %v", err)
+		return
+	}
+	err = s.RemoveIdentifier(types.IdentifierAlias, "driver")
+	if err != nil {
+		t.Errorf("RemoveIdentifier(): error = %v", err)
+		return
+	}
+	_, err = s.Settle(s.clock())
+	if err != nil {
+		t.Errorf("Settle(): error = %v", err)
+		return
+	}
+
+	want := []EventType{
+		EventMerchantRegistered, EventCategoryAdded, EventCategoryUpdated, EventCategoryAssigned,
+		EventAccountRegistered, EventIdentifierAdded, EventIdentifierRemoved, EventSettled,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Subscribe(): expected: %v, actual: %v", want, got)
+	}
+}
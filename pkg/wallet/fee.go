@@ -0,0 +1,136 @@
+package wallet
+
+import (
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+// FeeRule - правило расчёта комиссии. Пустые Category и Tier подходят к любому платежу.
+// Комиссия = Fixed + Amount * BasisPoints / 10000, затем ограничивается Min и Max (0 - без ограничения).
+type FeeRule struct {
+	Category    types.PaymentCategory
+	Tier        string
+	BasisPoints int64 // 100 = 1%
+	Fixed       types.Money
+	Min         types.Money
+	Max         types.Money
+}
+
+// FeeSchedule - набор правил комиссий и уровни (тарифы) счетов.
+// Для платежа выбирается самое точное правило: уровень и категория, затем уровень,
+// затем категория, затем общее; при равенстве - первое в списке.
+type FeeSchedule struct {
+	Rules []FeeRule
+	Tiers map[int64]string
+}
+
+// Fee считает комиссию за платёж amount со счёта accountID в категории category
+func (fs FeeSchedule) Fee(accountID int64, amount types.Money, category types.PaymentCategory) types.Money {
+	tier := fs.Tiers[accountID]
+
+	var rule *FeeRule
+	best := -1
+	for i := range fs.Rules {
+		r := &fs.Rules[i]
+		if r.Category != "" && r.Category != category || r.Tier != "" && r.Tier != tier {
+			continue
+		}
+
+		score := 0
+		if r.Tier != "" {
+			score += 2
+		}
+		if r.Category != "" {
+			score++
+		}
+		if score > best {
+			rule, best = r, score
+		}
+	}
+	if rule == nil {
+		return 0
+	}
+
+	fee := rule.Fixed + types.Money(int64(amount)*rule.BasisPoints/10000)
+	if fee < rule.Min {
+		fee = rule.Min
+	}
+	if rule.Max > 0 && fee > rule.Max {
+		fee = rule.Max
+	}
+	return fee
+}
+
+// SetFeeSchedule задаёт правила комиссий для Pay, Repeat и PayFromFavorite.
+// Без правил комиссия не берётся.
+func (s *Service) SetFeeSchedule(schedule FeeSchedule) {
+	s.feeSchedule = schedule
+}
+
+// FindFeeByPaymentID возвращает комиссию, списанную за платёж
+func (s *Service) FindFeeByPaymentID(paymentID string) (*types.Fee, error) {
+	if _, err := s.FindPaymentByID(paymentID); err != nil {
+		return nil, err
+	}
+
+	fee := s.feeFor(paymentID)
+	if fee == nil {
+		return nil, ErrFeeNotFound
+	}
+	return fee, nil
+}
+
+func (s *Service) feeFor(paymentID string) *types.Fee {
+	for _, fee := range s.fees {
+		if fee.PaymentID == paymentID {
+			return fee
+		}
+	}
+	return nil
+}
+
+func (s *Service) addFee(payment *types.Payment, amount types.Money) error {
+	if amount <= 0 {
+		return nil
+	}
+	id, err := s.newID()
+	if err != nil {
+		return err
+	}
+	s.fees = append(s.fees, &types.Fee{
+		ID:        id,
+		PaymentID: payment.ID,
+		AccountID: payment.AccountID,
+		Amount:    amount,
+	})
+	return nil
+}
+
+// refundedFee возвращает комиссию, уже возвращённую по платежу через Refund
+func (s *Service) refundedFee(paymentID string) types.Money {
+	sum := types.Money(0)
+	for _, refund := range s.refunds {
+		if refund.PaymentID == paymentID {
+			sum += refund.Fee
+		}
+	}
+	return sum
+}
+
+// refundFee возвращает ещё не возвращённую часть комиссии, пропорциональную сумме refunded,
+// возвращённой по платежу всего, включая текущий возврат. Доля считается от общей суммы,
+// а не от каждого возврата, чтобы мелкие возвраты не теряли комиссию на округлении.
+func (s *Service) refundFee(payment *types.Payment, refunded types.Money) types.Money {
+	if payment.Amount <= 0 {
+		return 0
+	}
+	fee := s.feeFor(payment.ID)
+	if fee == nil {
+		return 0
+	}
+	refund := types.Money(int64(fee.Amount)*int64(refunded)/int64(payment.Amount)) - fee.Refunded
+	if refund <= 0 {
+		return 0
+	}
+	fee.Refunded += refund
+	return refund
+}
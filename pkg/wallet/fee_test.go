@@ -0,0 +1,173 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func TestFeeSchedule_Fee(t *testing.T) {
+	schedule := FeeSchedule{
+		Rules: []FeeRule{
+			{BasisPoints: 50},
+			{Category: "auto", BasisPoints: 100, Min: 50},
+			{Category: "shop", Fixed: 2_00},
+			{Tier: "gold", BasisPoints: 10, Max: 1_00},
+			{Tier: "gold", Category: "shop"},
+		},
+		Tiers: map[int64]string{2: "gold"},
+	}
+
+	tests := []struct {
+		accountID int64
+		amount    types.Money
+		category  types.PaymentCategory
+		want      types.Money
+	}{
+		{1, 10_000, "food", 50},
+		{1, 10_000, "auto", 100},
+		{1, 1_000, "auto", 50},
+		{1, 10_000, "shop", 2_00},
+		{2, 10_000, "auto", 10},
+		{2, 10_000_00, "auto", 1_00},
+		{2, 10_000, "shop", 0},
+	}
+	for _, tt := range tests {
+		got := schedule.Fee(tt.accountID, tt.amount, tt.category)
+		if got != tt.want {
+			t.Errorf("Fee(%d, %d, %s): expected: %d, actual: %d", tt.accountID, tt.amount, tt.category, tt.want, got)
+		}
+	}
+}
+
+func TestService_Pay_fee(t *testing.T) {
+	s := newTestService()
+	s.SetFeeSchedule(FeeSchedule{Rules: []FeeRule{{Category: "auto", BasisPoints: 100}}})
+
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	payment := payments[0]
+	if account.Balance != defaultTestAccount.balance-payment.Amount-100 {
+		t.Errorf("Pay(): fee not debited, balance = %d", account.Balance)
+		return
+	}
+
+	fee, err := s.FindFeeByPaymentID(payment.ID)
+	if err != nil {
+		t.Errorf("FindFeeByPaymentID(): error = %v", err)
+		return
+	}
+	if fee.Amount != 100 || fee.AccountID != account.ID {
+		t.Errorf("FindFeeByPaymentID(): wrong fee = %v", fee)
+		return
+	}
+
+	refund, err := s.Refund(payment.ID, payment.Amount/2)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+	// возвращена половина платежа - возвращается половина комиссии
+	if refund.Fee != 50 || fee.Refunded != 50 || account.Balance != defaultTestAccount.balance-payment.Amount/2-50 {
+		t.Errorf("Refund(): wrong fee refund = %v, balance = %d", fee, account.Balance)
+		return
+	}
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	// отмена возвращает остаток платежа и остаток комиссии
+	if fee.Refunded != 100 || account.Balance != defaultTestAccount.balance {
+		t.Errorf("Reject(): wrong fee refund = %v, balance = %d", fee, account.Balance)
+		return
+	}
+	if discrepancies := s.Reconcile(); len(discrepancies) != 0 {
+		t.Errorf("Reconcile(): expected no discrepancies, actual: %v", discrepancies)
+	}
+}
+
+func TestService_Refund_fee(t *testing.T) {
+	s := newTestService()
+	s.SetFeeSchedule(FeeSchedule{Rules: []FeeRule{{Category: "auto", BasisPoints: 100}}})
+
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	payment := payments[0]
+	_, err = s.Refund(payment.ID, payment.Amount)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+	if account.Balance != defaultTestAccount.balance {
+		t.Errorf("Refund(): fee not returned with full refund, balance = %d", account.Balance)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := &Service{}
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	if len(imported.refunds) != 1 || imported.refunds[0].Fee != 100 {
+		t.Errorf("Import(): refund fee not preserved = %v", imported.refunds)
+		return
+	}
+	if discrepancies := imported.Reconcile(); len(discrepancies) != 0 {
+		t.Errorf("Reconcile(): expected no discrepancies, actual: %v", discrepancies)
+	}
+}
+
+func TestService_Refund_smallPartsFee(t *testing.T) {
+	s := newTestService()
+	s.SetFeeSchedule(FeeSchedule{Rules: []FeeRule{{Fixed: 1}}})
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 100)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	payment, err := s.Pay(account.ID, 3, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	// по отдельности каждый возврат округлял бы комиссию до нуля
+	for i := 0; i < 3; i++ {
+		_, err = s.Refund(payment.ID, 1)
+		if err != nil {
+			t.Errorf("Refund(): error = %v", err)
+			return
+		}
+	}
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	if account.Balance != 100 {
+		t.Errorf("Reject(): fee kept on reversed payment, balance = %d", account.Balance)
+		return
+	}
+	if discrepancies := s.Reconcile(); len(discrepancies) != 0 {
+		t.Errorf("Reconcile(): expected no discrepancies, actual: %v", discrepancies)
+	}
+}
@@ -0,0 +1,277 @@
+package wallet
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrInvalidPartition = errors.New("invalid history partition")
+var ErrHistoryCorrupted = errors.New("history files do not match manifest")
+
+// HistoryManifestFile - имя файла манифеста в каталоге выгрузки истории
+const HistoryManifestFile = "payments.manifest.json"
+
+// PartitionMode - способ разбиения истории платежей на файлы
+type PartitionMode string
+
+const (
+	PartitionByCount PartitionMode = "count"
+	PartitionBySize  PartitionMode = "size"
+	PartitionByMonth PartitionMode = "month"
+)
+
+// HistoryPartition - параметры разбиения: не больше Records записей или Bytes байт в файле,
+// либо по одному файлу на календарный месяц (UTC) времени платежа
+type HistoryPartition struct {
+	Mode    PartitionMode
+	Records int
+	Bytes   int64
+}
+
+// HistoryFile - описание одного файла выгрузки в манифесте
+type HistoryFile struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	Bytes   int64  `json:"bytes"`
+	Month   string `json:"month,omitempty"`
+}
+
+// HistoryManifest - манифест выгрузки: способ разбиения и файлы в порядке чтения
+type HistoryManifest struct {
+	Mode    PartitionMode `json:"mode"`
+	Records int           `json:"records"`
+	Files   []HistoryFile `json:"files"`
+}
+
+// HistoryToFiles выгружает платежи в dir по records записей в файле
+func (s *Service) HistoryToFiles(payments []types.Payment, dir string, records int) error {
+	_, err := s.ExportHistory(payments, dir, HistoryPartition{Mode: PartitionByCount, Records: records})
+	return err
+}
+
+// ExportHistory выгружает платежи в dir файлами payments-0001.dump, payments-0002.dump, ...
+// (при разбиении по месяцам - payments-2024-05.dump) и записывает манифест.
+// Файлы прошлой выгрузки, которых нет в новом манифесте, удаляются.
+func (s *Service) ExportHistory(payments []types.Payment, dir string, partition HistoryPartition) (*HistoryManifest, error) {
+	parts, err := partitionHistory(payments, partition)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := ReadHistoryManifest(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	manifest := &HistoryManifest{Mode: partition.Mode, Records: len(payments), Files: []HistoryFile{}}
+	for i, part := range parts {
+		file := HistoryFile{Name: fmt.Sprintf("payments-%04d.dump", i+1), Records: len(part.lines), Month: part.month}
+		if partition.Mode == PartitionByMonth {
+			file.Name = "payments-" + part.month + ".dump"
+		}
+
+		data := strings.Join(part.lines, "")
+		file.Bytes = int64(len(data))
+		err = os.WriteFile(filepath.Join(dir, file.Name), []byte(data), 0666)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(filepath.Join(dir, HistoryManifestFile), append(data, '\n'), 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous != nil {
+		written := make(map[string]bool)
+		for _, file := range manifest.Files {
+			written[file.Name] = true
+		}
+		for _, file := range previous.Files {
+			if written[file.Name] {
+				continue
+			}
+			err = os.Remove(filepath.Join(dir, filepath.Base(file.Name)))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+	}
+	return manifest, nil
+}
+
+// ReadHistoryManifest читает манифест выгрузки из dir
+func ReadHistoryManifest(dir string) (*HistoryManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, HistoryManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest HistoryManifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// ReadHistory читает выгрузку ExportHistory обратно в порядке файлов манифеста.
+// Если размер или число записей файла не совпадает с манифестом, возвращается ErrHistoryCorrupted.
+func ReadHistory(dir string) ([]types.Payment, error) {
+	manifest, err := ReadHistoryManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := []types.Payment{}
+	for _, file := range manifest.Files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.Base(file.Name)))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) != file.Bytes {
+			return nil, fmt.Errorf("%s: %w", file.Name, ErrHistoryCorrupted)
+		}
+
+		records := 0
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		for scanner.Scan() {
+			payment, err := parseHistoryRecord(scanner.Text())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file.Name, err)
+			}
+			payments = append(payments, payment)
+			records++
+		}
+		if records != file.Records {
+			return nil, fmt.Errorf("%s: %w", file.Name, ErrHistoryCorrupted)
+		}
+	}
+	if len(payments) != manifest.Records {
+		return nil, ErrHistoryCorrupted
+	}
+	return payments, nil
+}
+
+type historyPart struct {
+	month string
+	lines []string
+	bytes int64
+}
+
+func partitionHistory(payments []types.Payment, partition HistoryPartition) ([]*historyPart, error) {
+	var parts []*historyPart
+	switch partition.Mode {
+	case PartitionByCount:
+		if partition.Records <= 0 {
+			return nil, ErrInvalidPartition
+		}
+		for i, payment := range payments {
+			if i%partition.Records == 0 {
+				parts = append(parts, &historyPart{})
+			}
+			parts[len(parts)-1].add(historyRecord(payment))
+		}
+	case PartitionBySize:
+		if partition.Bytes <= 0 {
+			return nil, ErrInvalidPartition
+		}
+		for _, payment := range payments {
+			line := historyRecord(payment)
+			// запись больше лимита всё равно попадает в файл, но одна
+			if len(parts) == 0 || parts[len(parts)-1].bytes+int64(len(line)) > partition.Bytes {
+				parts = append(parts, &historyPart{})
+			}
+			parts[len(parts)-1].add(line)
+		}
+	case PartitionByMonth:
+		months := make(map[string]*historyPart)
+		for _, payment := range payments {
+			month := "undated"
+			if !payment.Time.IsZero() {
+				month = payment.Time.UTC().Format("2006-01")
+			}
+			part, ok := months[month]
+			if !ok {
+				part = &historyPart{month: month}
+				months[month] = part
+				parts = append(parts, part)
+			}
+			part.add(historyRecord(payment))
+		}
+		sort.Slice(parts, func(i, j int) bool {
+			return parts[i].month < parts[j].month
+		})
+	default:
+		return nil, ErrInvalidPartition
+	}
+	return parts, nil
+}
+
+func (p *historyPart) add(line string) {
+	p.lines = append(p.lines, line)
+	p.bytes += int64(len(line))
+}
+
+// historyRecord записывает платёж в формате payments.dump
+func historyRecord(payment types.Payment) string {
+	return payment.ID + ";" + strconv.FormatInt(payment.AccountID, 10) + ";" + strconv.FormatInt(int64(payment.Amount), 10) + ";" +
+		string(payment.Category) + ";" + string(payment.Status) + ";" + formatDumpTime(payment.Time) + ";" + strconv.FormatInt(payment.Seq, 10) + ";" +
+		strconv.FormatInt(payment.Changed, 10) + "\n"
+}
+
+func parseHistoryRecord(line string) (types.Payment, error) {
+	fields := strings.Split(line, ";")
+	if len(fields) < 5 {
+		return types.Payment{}, ErrHistoryCorrupted
+	}
+	aid, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return types.Payment{}, err
+	}
+	amount, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return types.Payment{}, err
+	}
+	created, err := parseDumpTime(fields, 5)
+	if err != nil {
+		return types.Payment{}, err
+	}
+	seq, err := parseDumpSeq(fields, 6)
+	if err != nil {
+		return types.Payment{}, err
+	}
+	changed, err := parseDumpSeq(fields, 7)
+	if err != nil {
+		return types.Payment{}, err
+	}
+
+	return types.Payment{
+		ID:        fields[0],
+		Seq:       seq,
+		Changed:   changed,
+		AccountID: aid,
+		Amount:    types.Money(amount),
+		Category:  types.PaymentCategory(fields[3]),
+		Status:    types.PaymentStatus(fields[4]),
+		Time:      created,
+	}, nil
+}
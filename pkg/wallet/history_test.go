@@ -0,0 +1,121 @@
+package wallet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func historyTestPayments() []types.Payment {
+	return []types.Payment{
+		{ID: "p1", Seq: 1, AccountID: 1, Amount: 100, Category: "food", Status: types.PaymentStatusOk, Time: time.Date(2024, 4, 30, 23, 0, 0, 0, time.UTC)},
+		{ID: "p2", Seq: 2, AccountID: 1, Amount: 200, Category: "taxi", Status: types.PaymentStatusInProgress, Time: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)},
+		{ID: "p3", Seq: 3, AccountID: 1, Amount: 300, Category: "food", Status: types.PaymentStatusFail, Time: time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)},
+		{ID: "p4", Seq: 4, AccountID: 2, Amount: 400, Category: "auto", Status: types.PaymentStatusOk},
+		{ID: "p5", Seq: 5, AccountID: 2, Amount: 500, Category: "auto", Status: types.PaymentStatusOk, Time: time.Date(2024, 4, 2, 8, 0, 0, 0, time.UTC)},
+	}
+}
+
+func TestService_ExportHistory_partitions(t *testing.T) {
+	s := newTestService()
+	payments := historyTestPayments()
+
+	tests := []struct {
+		name      string
+		partition HistoryPartition
+		files     []string
+		records   []int
+	}{
+		{"count", HistoryPartition{Mode: PartitionByCount, Records: 2}, []string{"payments-0001.dump", "payments-0002.dump", "payments-0003.dump"}, []int{2, 2, 1}},
+		{"size", HistoryPartition{Mode: PartitionBySize, Bytes: 110}, []string{"payments-0001.dump", "payments-0002.dump"}, []int{2, 3}},
+		{"month", HistoryPartition{Mode: PartitionByMonth}, []string{"payments-2024-04.dump", "payments-2024-05.dump", "payments-undated.dump"}, []int{2, 2, 1}},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		manifest, err := s.ExportHistory(payments, dir, tt.partition)
+		if err != nil {
+			t.Errorf("%s: ExportHistory(): error = %v", tt.name, err)
+			continue
+		}
+
+		var files []string
+		var records []int
+		for _, file := range manifest.Files {
+			files = append(files, file.Name)
+			records = append(records, file.Records)
+		}
+		if !reflect.DeepEqual(files, tt.files) || !reflect.DeepEqual(records, tt.records) {
+			t.Errorf("%s: ExportHistory(): wrong files = %v, records = %v", tt.name, files, records)
+			continue
+		}
+
+		got, err := ReadHistory(dir)
+		if err != nil {
+			t.Errorf("%s: ReadHistory(): error = %v", tt.name, err)
+			continue
+		}
+		if len(got) != len(payments) {
+			t.Errorf("%s: ReadHistory(): wrong payments = %v", tt.name, got)
+			continue
+		}
+		if tt.partition.Mode == PartitionByCount && !reflect.DeepEqual(got, payments) {
+			t.Errorf("%s: ReadHistory(): expected: %v, actual: %v", tt.name, payments, got)
+		}
+	}
+}
+
+func TestService_ExportHistory_fail(t *testing.T) {
+	s := newTestService()
+	payments := historyTestPayments()
+	dir := t.TempDir()
+
+	_, err := s.ExportHistory(payments, dir, HistoryPartition{Mode: PartitionByCount})
+	if err != ErrInvalidPartition {
+		t.Errorf("ExportHistory(): wrong error for zero records = %v", err)
+		return
+	}
+	err = s.HistoryToFiles(payments, filepath.Join(dir, "file"), 0)
+	if err != ErrInvalidPartition {
+		t.Errorf("HistoryToFiles(): wrong error for zero records = %v", err)
+		return
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "file"), nil, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.HistoryToFiles(payments, filepath.Join(dir, "file"), 2)
+	if err == nil {
+		t.Errorf("HistoryToFiles(): expected error when dir is a file")
+		return
+	}
+
+	_, err = s.ExportHistory(payments, dir, HistoryPartition{Mode: PartitionByCount, Records: 1})
+	if err != nil {
+		t.Errorf("ExportHistory(): error = %v", err)
+		return
+	}
+	_, err = s.ExportHistory(payments, dir, HistoryPartition{Mode: PartitionByCount, Records: 4})
+	if err != nil {
+		t.Errorf("ExportHistory(): error = %v", err)
+		return
+	}
+	if _, err := os.Stat(filepath.Join(dir, "payments-0005.dump")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ExportHistory(): stale file left, stat error = %v", err)
+		return
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "payments-0002.dump"), []byte("p9;1;1;food;OK;\n"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadHistory(dir)
+	if !errors.Is(err, ErrHistoryCorrupted) {
+		t.Errorf("ReadHistory(): wrong error for changed file = %v", err)
+	}
+}
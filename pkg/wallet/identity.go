@@ -0,0 +1,313 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrIdentifierTaken = errors.New("identifier already registered")
+var ErrIdentifierNotFound = errors.New("identifier not found")
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+var ErrTransferToSelf = errors.New("can't transfer to the same account")
+var ErrTransferFavorite = errors.New("transfer can't be paid from favorite")
+
+// TransferCategory - категория платежей, созданных Transfer
+const TransferCategory types.PaymentCategory = "transfer"
+
+func (s *Service) FindAccountByPhone(phone types.Phone) (*types.Account, error) {
+	phone, err := s.normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, account := range s.accounts {
+		if account.Phone == phone {
+			return account, nil
+		}
+	}
+
+	return nil, ErrAccountNotFound
+}
+
+// ChangePhone меняет номер счёта и записывает смену в историю
+func (s *Service) ChangePhone(accountID int64, phone types.Phone) (rerr error) {
+	defer s.audited("ChangePhone", auditArgs("account_id", accountID, "phone", phone))(&rerr)
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+
+	phone, err = s.normalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	if phone == account.Phone {
+		return nil
+	}
+
+	for _, acc := range s.accounts {
+		if acc.Phone == phone {
+			return ErrPhoneRegistered
+		}
+	}
+
+	change := &types.PhoneChange{
+		AccountID: account.ID,
+		OldPhone:  account.Phone,
+		NewPhone:  phone,
+		Time:      s.clock(),
+	}
+	s.phoneChanges = append(s.phoneChanges, change)
+	account.Phone = phone
+	s.publish(PhoneChanged{Change: *change})
+	return nil
+}
+
+// PhoneHistory возвращает все смены номера счёта, от старых к новым
+func (s *Service) PhoneHistory(accountID int64) ([]types.PhoneChange, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []types.PhoneChange
+	for _, change := range s.phoneChanges {
+		if change.AccountID == accountID {
+			changes = append(changes, *change)
+		}
+	}
+	return changes, nil
+}
+
+// AddIdentifier привязывает к счёту email или псевдоним, уникальный среди всех счетов
+func (s *Service) AddIdentifier(accountID int64, kind types.IdentifierKind, value string) (_ *types.Identifier, rerr error) {
+	defer s.audited("AddIdentifier", auditArgs("account_id", accountID, "kind", kind, "value", value))(&rerr)
+
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err = normalizeIdentifier(kind, value)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, identifier := range s.identifiers {
+		if identifier.Kind == kind && identifier.Value == value {
+			return nil, ErrIdentifierTaken
+		}
+	}
+
+	identifier := &types.Identifier{
+		AccountID: accountID,
+		Kind:      kind,
+		Value:     value,
+	}
+	s.identifiers = append(s.identifiers, identifier)
+	s.publish(IdentifierAdded{Identifier: *identifier})
+	return identifier, nil
+}
+
+func (s *Service) RemoveIdentifier(kind types.IdentifierKind, value string) (rerr error) {
+	defer s.audited("RemoveIdentifier", auditArgs("kind", kind, "value", value))(&rerr)
+
+	value, err := normalizeIdentifier(kind, value)
+	if err != nil {
+		return err
+	}
+
+	for i, identifier := range s.identifiers {
+		if identifier.Kind == kind && identifier.Value == value {
+			s.identifiers = append(s.identifiers[:i], s.identifiers[i+1:]...)
+			s.publish(IdentifierRemoved{Identifier: *identifier})
+			return nil
+		}
+	}
+
+	return ErrIdentifierNotFound
+}
+
+// Identifiers возвращает все дополнительные идентификаторы счёта
+func (s *Service) Identifiers(accountID int64) ([]types.Identifier, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var identifiers []types.Identifier
+	for _, identifier := range s.identifiers {
+		if identifier.AccountID == accountID {
+			identifiers = append(identifiers, *identifier)
+		}
+	}
+	return identifiers, nil
+}
+
+// ResolveAccount ищет счёт по номеру телефона, email или псевдониму
+func (s *Service) ResolveAccount(address string) (*types.Account, error) {
+	kind := types.IdentifierAlias
+	if strings.Contains(address, "@") {
+		kind = types.IdentifierEmail
+	} else if account, err := s.FindAccountByPhone(types.Phone(address)); err == nil {
+		return account, nil
+	}
+
+	value, err := normalizeIdentifier(kind, address)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+	for _, identifier := range s.identifiers {
+		if identifier.Kind == kind && identifier.Value == value {
+			return s.FindAccountByID(identifier.AccountID)
+		}
+	}
+
+	return nil, ErrAccountNotFound
+}
+
+// Transfer переводит amount со счёта fromAccountID на счёт, найденный по адресу to
+func (s *Service) Transfer(fromAccountID int64, to string, amount types.Money) (_ *types.Payment, rerr error) {
+	defer s.audited("Transfer", auditArgs("from_account_id", fromAccountID, "to", to, "amount", amount))(&rerr)
+
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	from, err := s.FindAccountByID(fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := s.ResolveAccount(to)
+	if err != nil {
+		return nil, err
+	}
+	if recipient.ID == from.ID {
+		return nil, ErrTransferToSelf
+	}
+
+	err = checkAccountActive(from)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAccountActive(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	// перевод проверяется теми же правилами риска, что и Pay
+	attempt := PaymentAttempt{AccountID: from.ID, Amount: amount, Category: TransferCategory, Time: s.clock()}
+	decision, reasons := s.checkRisk(attempt)
+	if decision == types.RiskDeny {
+		if err := s.recordRisk(attempt, decision, reasons, ""); err != nil {
+			return nil, err
+		}
+		return nil, ErrPaymentDenied
+	}
+	status := types.PaymentStatusInProgress
+	if decision == types.RiskReview {
+		status = types.PaymentStatusReview
+	}
+
+	payment, err := s.pay(from, amount, TransferCategory, 0, status)
+	if err != nil {
+		return nil, err
+	}
+	if s.risk != nil {
+		s.risk.remember(attempt)
+	}
+	if decision == types.RiskReview {
+		if err := s.recordRisk(attempt, decision, reasons, payment.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// задержанный перевод зачисляется получателю после ApproveReview
+	if status != types.PaymentStatusReview {
+		recipient.Balance += amount
+	}
+	s.transfers = append(s.transfers, &types.Transfer{
+		PaymentID:   payment.ID,
+		ToAccountID: recipient.ID,
+	})
+	s.publish(Transferred{Payment: *payment, ToAccountID: recipient.ID})
+	return payment, nil
+}
+
+// creditTransfer зачисляет получателю одобренный задержанный перевод
+func (s *Service) creditTransfer(payment *types.Payment) error {
+	transfer := s.findTransfer(payment.ID)
+	if transfer == nil {
+		return nil
+	}
+	recipient, err := s.FindAccountByID(transfer.ToAccountID)
+	if err != nil {
+		return err
+	}
+	if recipient.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+	recipient.Balance += payment.Amount
+	return nil
+}
+
+// findTransfer возвращает перевод, созданный платежом paymentID, или nil
+func (s *Service) findTransfer(paymentID string) *types.Transfer {
+	for _, transfer := range s.transfers {
+		if transfer.PaymentID == paymentID {
+			return transfer
+		}
+	}
+	return nil
+}
+
+// reverseTransfer списывает amount с получателя, если платёж был переводом
+func (s *Service) reverseTransfer(paymentID string, amount types.Money) error {
+	for _, transfer := range s.transfers {
+		if transfer.PaymentID != paymentID {
+			continue
+		}
+
+		recipient, err := s.FindAccountByID(transfer.ToAccountID)
+		if err != nil {
+			return err
+		}
+		if recipient.Balance < amount {
+			return ErrNotEnoughBalance
+		}
+		recipient.Balance -= amount
+		return nil
+	}
+	return nil
+}
+
+func normalizeIdentifier(kind types.IdentifierKind, value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	switch kind {
+	case types.IdentifierEmail:
+		at := strings.Index(value, "@")
+		if at <= 0 || at != strings.LastIndex(value, "@") || !strings.Contains(value[at:], ".") || strings.ContainsAny(value, " ;\n") {
+			return "", ErrInvalidIdentifier
+		}
+		return value, nil
+	case types.IdentifierAlias:
+		// псевдоним начинается с буквы, чтобы его нельзя было спутать с номером телефона
+		if len(value) < 3 || len(value) > 32 || value[0] < 'a' || value[0] > 'z' {
+			return "", ErrInvalidIdentifier
+		}
+		for _, c := range value {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '.') {
+				return "", ErrInvalidIdentifier
+			}
+		}
+		return value, nil
+	}
+	return "", ErrInvalidIdentifier
+}
@@ -0,0 +1,198 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func TestService_ChangePhone_success(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+
+	err = s.ChangePhone(account.ID, "92 642 15 00")
+	if err != nil {
+		t.Errorf("ChangePhone(): error = %v", err)
+		return
+	}
+
+	found, err := s.FindAccountByPhone("+992926421500")
+	if err != nil || found.ID != account.ID {
+		t.Errorf("FindAccountByPhone(): expected: %v, actual: %v, error = %v", account, found, err)
+		return
+	}
+	_, err = s.FindAccountByPhone(defaultTestAccount.phone)
+	if err != ErrAccountNotFound {
+		t.Errorf("FindAccountByPhone(): old phone must not be found, returned = %v", err)
+		return
+	}
+
+	history, err := s.PhoneHistory(account.ID)
+	if err != nil {
+		t.Errorf("PhoneHistory(): error = %v", err)
+		return
+	}
+	if len(history) != 1 || history[0].OldPhone != defaultTestAccount.phone || history[0].NewPhone != "+992926421500" {
+		t.Errorf("PhoneHistory(): wrong history = %v", history)
+	}
+}
+
+func TestService_ChangePhone_fail_registered(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	_, err = s.RegisterAccount("+992926421500")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+
+	err = s.ChangePhone(account.ID, "+992926421500")
+	if err != ErrPhoneRegistered {
+		t.Errorf("ChangePhone(): must return ErrPhoneRegistered, returned = %v", err)
+	}
+}
+
+func TestService_Transfer_success(t *testing.T) {
+	s := newTestService()
+	from, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	to, err := s.RegisterAccount("+992926421500")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+
+	_, err = s.AddIdentifier(to.ID, types.IdentifierEmail, "Friend@Example.com")
+	if err != nil {
+		t.Errorf("AddIdentifier(): error = %v", err)
+		return
+	}
+	_, err = s.AddIdentifier(to.ID, types.IdentifierAlias, "friend")
+	if err != nil {
+		t.Errorf("AddIdentifier(): error = %v", err)
+		return
+	}
+	_, err = s.AddIdentifier(from.ID, types.IdentifierAlias, "FRIEND")
+	if err != ErrIdentifierTaken {
+		t.Errorf("AddIdentifier(): must return ErrIdentifierTaken, returned = %v", err)
+		return
+	}
+
+	for _, address := range []string{"friend@example.com", "friend", "926421500"} {
+		_, err = s.Transfer(from.ID, address, 100)
+		if err != nil {
+			t.Errorf("Transfer(%v): error = %v", address, err)
+			return
+		}
+	}
+	if to.Balance != 300 {
+		t.Errorf("Transfer(): wrong recipient balance: %v", to.Balance)
+		return
+	}
+
+	payment, err := s.Transfer(from.ID, "friend", 500)
+	if err != nil {
+		t.Errorf("Transfer(): error = %v", err)
+		return
+	}
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	if to.Balance != 300 || from.Balance != 990_000-300 {
+		t.Errorf("Reject(): transfer must be reversed, from: %v, to: %v", from.Balance, to.Balance)
+	}
+
+	_, err = s.Transfer(from.ID, "nobody", 100)
+	if err != ErrAccountNotFound {
+		t.Errorf("Transfer(): must return ErrAccountNotFound, returned = %v", err)
+	}
+}
+
+func TestService_Repeat_transfer(t *testing.T) {
+	s := newTestService()
+	from, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	to, err := s.RegisterAccount("+992926421500")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+
+	payment, err := s.Transfer(from.ID, "926421500", 1_000)
+	if err != nil {
+		t.Errorf("Transfer(): error = %v", err)
+		return
+	}
+	repeated, err := s.Repeat(payment.ID)
+	if err != nil {
+		t.Errorf("Repeat(): error = %v", err)
+		return
+	}
+	if from.Balance != 990_000-2_000 || to.Balance != 2_000 {
+		t.Errorf("Repeat(): transfer must be credited to the recipient, from: %v, to: %v", from.Balance, to.Balance)
+		return
+	}
+	if transfer := s.findTransfer(repeated.ID); transfer == nil || transfer.ToAccountID != to.ID {
+		t.Errorf("Repeat(): transfer not recorded = %v", transfer)
+		return
+	}
+
+	_, err = s.FavoritePayment(payment.ID, "friend")
+	if err != ErrTransferFavorite {
+		t.Errorf("FavoritePayment(): must return ErrTransferFavorite, returned = %v", err)
+	}
+}
+
+func TestService_Export_phoneChanges(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	err = s.ChangePhone(account.ID, "+992926421500")
+	if err != nil {
+		t.Errorf("ChangePhone(): error = %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := &Service{}
+	for i := 0; i < 2; i++ {
+		err = imported.Import(dir)
+		if err != nil {
+			t.Errorf("Import(): error = %v", err)
+			return
+		}
+	}
+
+	history, err := imported.PhoneHistory(account.ID)
+	if err != nil {
+		t.Errorf("PhoneHistory(): error = %v", err)
+		return
+	}
+	if len(history) != 1 || history[0].OldPhone != defaultTestAccount.phone || history[0].NewPhone != "+992926421500" {
+		t.Errorf("PhoneHistory(): wrong history after Import = %v", history)
+	}
+}
@@ -0,0 +1,158 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var ErrIDCollision = errors.New("generated id is invalid or already in use")
+
+// IDGenerator выдаёт идентификаторы новых записей сервиса
+type IDGenerator interface {
+	// NewID возвращает идентификатор платежа, избранного, возврата и других записей
+	NewID() string
+	// NewAccountID возвращает номер нового счёта; last - наибольший из уже занятых номеров
+	NewAccountID(last int64) int64
+}
+
+// UUIDGenerator выдаёт случайные UUID версии 4; используется по умолчанию
+type UUIDGenerator struct{}
+
+func (UUIDGenerator) NewID() string {
+	return uuid.New().String()
+}
+
+func (UUIDGenerator) NewAccountID(last int64) int64 {
+	return nextAccountID(last)
+}
+
+// TimeOrderedGenerator выдаёт UUID версии 7: строки идентификаторов сортируются по времени создания
+type TimeOrderedGenerator struct{}
+
+func (TimeOrderedGenerator) NewID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		// NewV7 ошибается только без источника случайности, как и uuid.New
+		panic(err)
+	}
+	return id.String()
+}
+
+func (TimeOrderedGenerator) NewAccountID(last int64) int64 {
+	return nextAccountID(last)
+}
+
+// SequentialGenerator выдаёт Prefix и номер по порядку (pay-00000001, pay-00000002, ...),
+// так что идентификаторы в тестах предсказуемы
+type SequentialGenerator struct {
+	Prefix string
+	next   int64
+}
+
+func NewSequentialGenerator(prefix string) *SequentialGenerator {
+	return &SequentialGenerator{Prefix: prefix}
+}
+
+func (g *SequentialGenerator) NewID() string {
+	g.next++
+	return fmt.Sprintf("%s%08d", g.Prefix, g.next)
+}
+
+func (g *SequentialGenerator) NewAccountID(last int64) int64 {
+	return nextAccountID(last)
+}
+
+// nextAccountID - номер счёта по умолчанию: следующий за наибольшим занятым
+func nextAccountID(last int64) int64 {
+	return last + 1
+}
+
+// SetIDGenerator задаёт генератор идентификаторов новых записей и счетов
+func (s *Service) SetIDGenerator(ids IDGenerator) {
+	s.ids = ids
+}
+
+// newID выдаёт идентификатор записи через генератор, пропуская уже занятые: после Import
+// SequentialGenerator снова начинает с первого номера
+func (s *Service) newID() (string, error) {
+	var ids IDGenerator = UUIDGenerator{}
+	if s.ids != nil {
+		ids = s.ids
+	}
+	if s.usedIDs == nil {
+		s.indexIDs()
+	}
+
+	// генератору, выдающему разные идентификаторы, хватит len(usedIDs)+1 попыток
+	for i := 0; i <= len(s.usedIDs); i++ {
+		id := ids.NewID()
+		if _, used := s.usedIDs[id]; id != "" && !used {
+			s.usedIDs[id] = struct{}{}
+			return id, nil
+		}
+	}
+	return "", ErrIDCollision
+}
+
+// indexIDs заново собирает занятые идентификаторы после загрузки записей извне
+func (s *Service) indexIDs() {
+	s.usedIDs = make(map[string]struct{})
+	for _, v := range s.payments {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.favorites {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.deleted {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.refunds {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.schedules {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.deposits {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.adjustments {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.fees {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.cashbacks {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.merchants {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.merchantEntries {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+	for _, v := range s.riskEvents {
+		s.usedIDs[v.ID] = struct{}{}
+	}
+}
+
+// newAccountID выдаёт номер счёта через генератор и не допускает повторов
+func (s *Service) newAccountID() (int64, error) {
+	var ids IDGenerator = UUIDGenerator{}
+	if s.ids != nil {
+		ids = s.ids
+	}
+
+	id := ids.NewAccountID(s.nextAccountID)
+	if id <= 0 {
+		return 0, ErrIDCollision
+	}
+	if _, err := s.FindAccountByID(id); err == nil {
+		return 0, ErrIDCollision
+	}
+	if id > s.nextAccountID {
+		s.nextAccountID = id
+	}
+	return id, nil
+}
@@ -0,0 +1,156 @@
+package wallet
+
+import (
+	"sort"
+	"testing"
+)
+
+type fixedAccountIDs struct {
+	SequentialGenerator
+	id int64
+}
+
+func (g *fixedAccountIDs) NewAccountID(last int64) int64 {
+	return g.id
+}
+
+func TestService_SetIDGenerator_sequential(t *testing.T) {
+	s := newTestService()
+	s.SetIDGenerator(NewSequentialGenerator("id-"))
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 10_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	payment, err := s.Pay(account.ID, 1_000, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	favorite, err := s.FavoritePayment(payment.ID, "lunch")
+	if err != nil {
+		t.Errorf("FavoritePayment(): error = %v", err)
+		return
+	}
+	if account.ID != 1 || s.deposits[0].ID != "id-00000001" || payment.ID != "id-00000002" || favorite.ID != "id-00000003" {
+		t.Errorf("wrong ids: account = %d, deposit = %s, payment = %s, favorite = %s", account.ID, s.deposits[0].ID, payment.ID, favorite.ID)
+	}
+}
+
+func TestService_Import_sequentialIDs(t *testing.T) {
+	s := newTestService()
+	s.SetIDGenerator(NewSequentialGenerator("id-"))
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 10_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	old, err := s.Pay(account.ID, 1_000, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := &Service{}
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	// новый генератор снова начинает с id-00000001, но занятые номера пропускаются
+	imported.SetIDGenerator(NewSequentialGenerator("id-"))
+	payment, err := imported.Pay(account.ID, 2_000, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	if payment.ID != "id-00000003" {
+		t.Errorf("Pay(): wrong id after import = %v", payment.ID)
+		return
+	}
+	found, err := imported.FindPaymentByID(old.ID)
+	if err != nil || found.Amount != 1_000 {
+		t.Errorf("FindPaymentByID(): wrong payment = %v, error = %v", found, err)
+	}
+}
+
+func TestService_RegisterAccount_idCollision(t *testing.T) {
+	s := newTestService()
+	s.SetIDGenerator(&fixedAccountIDs{id: 7})
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil || account.ID != 7 {
+		t.Errorf("RegisterAccount(): wrong account = %v, error = %v", account, err)
+		return
+	}
+	_, err = s.RegisterAccount("+992000000002")
+	if err != ErrIDCollision {
+		t.Errorf("RegisterAccount(): wrong error for repeated id = %v", err)
+		return
+	}
+
+	s.SetIDGenerator(UUIDGenerator{})
+	account, err = s.RegisterAccount("+992000000002")
+	if err != nil || account.ID != 8 {
+		t.Errorf("RegisterAccount(): wrong account after fixed ids = %v, error = %v", account, err)
+	}
+}
+
+func TestTimeOrderedGenerator_NewID(t *testing.T) {
+	var ids []string
+	for i := 0; i < 100; i++ {
+		ids = append(ids, TimeOrderedGenerator{}.NewID())
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("NewID(): ids are not ordered by creation = %v", ids)
+	}
+}
+
+type constantIDs struct {
+	SequentialGenerator
+}
+
+func (g *constantIDs) NewID() string {
+	return "same"
+}
+
+func TestService_Pay_idCollision(t *testing.T) {
+	s := newTestService()
+	s.SetIDGenerator(&constantIDs{})
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 10_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	// генератор, повторяющий идентификатор, получает ошибку, а не падение процесса
+	_, err = s.Pay(account.ID, 1_000, "food")
+	if err != ErrIDCollision {
+		t.Errorf("Pay(): error = %v, want %v", err, ErrIDCollision)
+		return
+	}
+	if account.Balance != 10_000 || len(s.payments) != 0 {
+		t.Errorf("Pay() changed state: balance = %d, payments = %d", account.Balance, len(s.payments))
+	}
+}
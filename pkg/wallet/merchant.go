@@ -0,0 +1,255 @@
+package wallet
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrMerchantNotFound = errors.New("merchant not found")
+var ErrInvalidMerchantName = errors.New("invalid merchant name")
+
+// SettlementLine - итог расчёта по одному мерчанту
+type SettlementLine struct {
+	MerchantID string      `json:"merchant_id"`
+	Name       string      `json:"name"`
+	Credits    types.Money `json:"credits"`
+	Debits     types.Money `json:"debits"`
+	Net        types.Money `json:"net"`
+}
+
+// Settlement - пакет расчёта с мерчантами за период до Until
+type Settlement struct {
+	ID    string           `json:"id"`
+	Until time.Time        `json:"until"`
+	Lines []SettlementLine `json:"lines"`
+}
+
+func (s *Service) RegisterMerchant(name string) (_ *types.Merchant, rerr error) {
+	defer s.audited("RegisterMerchant", auditArgs("name", name))(&rerr)
+
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, ";\n") {
+		return nil, ErrInvalidMerchantName
+	}
+
+	id, err := s.newID()
+	if err != nil {
+		return nil, err
+	}
+	merchant := &types.Merchant{
+		ID:   id,
+		Name: name,
+	}
+	s.merchants = append(s.merchants, merchant)
+	s.publish(MerchantRegistered{Merchant: *merchant})
+	return merchant, nil
+}
+
+func (s *Service) FindMerchantByID(merchantID string) (*types.Merchant, error) {
+	for _, merchant := range s.merchants {
+		if merchant.ID == merchantID {
+			return merchant, nil
+		}
+	}
+
+	return nil, ErrMerchantNotFound
+}
+
+// AssignCategory передаёт категорию из справочника мерчанту: платежи в неё и в дочерние
+// категории без своего мерчанта зачисляются ему
+func (s *Service) AssignCategory(merchantID string, code types.PaymentCategory) (rerr error) {
+	defer s.audited("AssignCategory", auditArgs("merchant_id", merchantID, "category", code))(&rerr)
+
+	merchant, err := s.FindMerchantByID(merchantID)
+	if err != nil {
+		return err
+	}
+	category, err := s.FindCategory(code)
+	if err != nil {
+		return err
+	}
+
+	category.Merchant = merchant.ID
+	s.publish(CategoryAssigned{MerchantID: merchant.ID, Category: category.Code})
+	return nil
+}
+
+// MerchantEntries возвращает все зачисления и списания мерчанта
+func (s *Service) MerchantEntries(merchantID string) ([]types.MerchantEntry, error) {
+	if _, err := s.FindMerchantByID(merchantID); err != nil {
+		return nil, err
+	}
+
+	var entries []types.MerchantEntry
+	for _, entry := range s.merchantEntries {
+		if entry.MerchantID == merchantID {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+// SettlementReport считает нерассчитанные суммы мерчантов за время до until, ничего не меняя
+func (s *Service) SettlementReport(until time.Time) []SettlementLine {
+	lines, _ := s.settlementLines(until)
+	return lines
+}
+
+// Settle закрывает расчётный период: нерассчитанные записи до until помечаются пакетом,
+// а чистая сумма по каждому мерчанту списывается с его баланса как выплаченная
+func (s *Service) Settle(until time.Time) (_ *Settlement, rerr error) {
+	defer s.audited("Settle", auditArgs("until", until.Format(time.RFC3339)))(&rerr)
+
+	id, err := s.newID()
+	if err != nil {
+		return nil, err
+	}
+	settlement := &Settlement{
+		ID:    id,
+		Until: until,
+	}
+	lines, entries := s.settlementLines(until)
+	for _, entry := range entries {
+		entry.BatchID = settlement.ID
+	}
+	for _, line := range lines {
+		merchant, err := s.FindMerchantByID(line.MerchantID)
+		if err != nil {
+			return nil, err
+		}
+		merchant.Balance -= line.Net
+	}
+	settlement.Lines = lines
+	s.publish(Settled{Settlement: *settlement})
+	return settlement, nil
+}
+
+// WriteSettlement записывает файл выплат по пакету в формате CSV
+func WriteSettlement(settlement *Settlement, writer io.Writer) error {
+	w := csv.NewWriter(writer)
+	err := w.Write([]string{"batch_id", "merchant_id", "name", "credits", "debits", "net"})
+	if err != nil {
+		return err
+	}
+	for _, line := range settlement.Lines {
+		err = w.Write([]string{
+			settlement.ID,
+			line.MerchantID,
+			line.Name,
+			strconv.FormatInt(int64(line.Credits), 10),
+			strconv.FormatInt(int64(line.Debits), 10),
+			strconv.FormatInt(int64(line.Net), 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func (s *Service) settlementLines(until time.Time) ([]SettlementLine, []*types.MerchantEntry) {
+	var lines []SettlementLine
+	var entries []*types.MerchantEntry
+	index := make(map[string]int)
+	for _, entry := range s.merchantEntries {
+		if entry.BatchID != "" || !entry.Time.Before(until) {
+			continue
+		}
+		// в дампах старого формата задержанные платежи могли быть зачислены до одобрения
+		if payment, err := s.FindPaymentByID(entry.PaymentID); err == nil && payment.Status == types.PaymentStatusReview {
+			continue
+		}
+		entries = append(entries, entry)
+
+		i, ok := index[entry.MerchantID]
+		if !ok {
+			name := ""
+			if merchant, err := s.FindMerchantByID(entry.MerchantID); err == nil {
+				name = merchant.Name
+			}
+			i = len(lines)
+			index[entry.MerchantID] = i
+			lines = append(lines, SettlementLine{MerchantID: entry.MerchantID, Name: name})
+		}
+		if entry.Amount > 0 {
+			lines[i].Credits += entry.Amount
+		} else {
+			lines[i].Debits -= entry.Amount
+		}
+		lines[i].Net += entry.Amount
+	}
+	return lines, entries
+}
+
+// merchantFor возвращает мерчанта категории или ближайшей родительской категории
+func (s *Service) merchantFor(code types.PaymentCategory) *types.Merchant {
+	for i := 0; i <= len(s.categories); i++ {
+		category, err := s.FindCategory(code)
+		if err != nil {
+			return nil
+		}
+		if category.Merchant != "" {
+			merchant, err := s.FindMerchantByID(category.Merchant)
+			if err != nil {
+				return nil
+			}
+			return merchant
+		}
+		if category.Parent == "" {
+			return nil
+		}
+		code = category.Parent
+	}
+	return nil
+}
+
+// creditMerchant зачисляет платёж мерчанту его категории
+func (s *Service) creditMerchant(payment *types.Payment) error {
+	merchant := s.merchantFor(payment.Category)
+	if merchant == nil {
+		return nil
+	}
+	return s.addMerchantEntry(merchant, payment, payment.Amount)
+}
+
+// debitMerchant списывает amount с мерчанта, которому был зачислен платёж
+func (s *Service) debitMerchant(payment *types.Payment, amount types.Money) error {
+	for _, entry := range s.merchantEntries {
+		if entry.PaymentID != payment.ID || entry.Amount <= 0 {
+			continue
+		}
+		merchant, err := s.FindMerchantByID(entry.MerchantID)
+		if err != nil {
+			return nil
+		}
+		return s.addMerchantEntry(merchant, payment, -amount)
+	}
+	return nil
+}
+
+func (s *Service) addMerchantEntry(merchant *types.Merchant, payment *types.Payment, amount types.Money) error {
+	if amount == 0 {
+		return nil
+	}
+
+	id, err := s.newID()
+	if err != nil {
+		return err
+	}
+	merchant.Balance += amount
+	s.merchantEntries = append(s.merchantEntries, &types.MerchantEntry{
+		ID:         id,
+		MerchantID: merchant.ID,
+		PaymentID:  payment.ID,
+		Amount:     amount,
+		Time:       s.clock(),
+	})
+	return nil
+}
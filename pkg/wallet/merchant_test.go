@@ -0,0 +1,98 @@
+package wallet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func TestService_Settle_success(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)}
+	s.SetClock(clock.Now)
+
+	merchant, err := s.RegisterMerchant("Auto Service")
+	if err != nil {
+		t.Errorf("RegisterMerchant(): error = %v", err)
+		return
+	}
+	err = s.AddCategory(types.Category{Code: "auto", Name: "Авто", Enabled: true})
+	if err != nil {
+		t.Errorf("AddCategory(): error = %v", err)
+		return
+	}
+	err = s.AddCategory(types.Category{Code: "fuel", Name: "Топливо", Parent: "auto", Enabled: true})
+	if err != nil {
+		t.Errorf("AddCategory(): error = %v", err)
+		return
+	}
+	err = s.AssignCategory(merchant.ID, "auto")
+	if err != nil {
+		t.Errorf("AssignCategory(): error = %v", err)
+		return
+	}
+
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	fuel, err := s.Pay(account.ID, 3_000, "fuel")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	_, err = s.Refund(payments[0].ID, 1_000)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+	err = s.Reject(fuel.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	if merchant.Balance != 9_000 {
+		t.Errorf("Balance: expected 9000, actual: %d", merchant.Balance)
+		return
+	}
+
+	// записи следующего дня в расчёт за сегодня не попадают
+	clock.now = clock.now.AddDate(0, 0, 1)
+	s.Pay(account.ID, 500, "auto")
+
+	settlement, err := s.Settle(time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Errorf("Settle(): error = %v", err)
+		return
+	}
+	want := SettlementLine{MerchantID: merchant.ID, Name: "Auto Service", Credits: 13_000, Debits: 4_000, Net: 9_000}
+	if len(settlement.Lines) != 1 || settlement.Lines[0] != want {
+		t.Errorf("Settle(): expected: %v, actual: %v", want, settlement.Lines)
+		return
+	}
+	if merchant.Balance != 500 {
+		t.Errorf("Settle(): expected balance 500 after payout, actual: %d", merchant.Balance)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = WriteSettlement(settlement, &buf)
+	if err != nil {
+		t.Errorf("WriteSettlement(): error = %v", err)
+		return
+	}
+	if !strings.Contains(buf.String(), merchant.ID+",Auto Service,13000,4000,9000") {
+		t.Errorf("WriteSettlement(): wrong file = %s", buf.String())
+		return
+	}
+
+	// повторный расчёт не включает уже рассчитанные записи
+	settlement, _ = s.Settle(time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC))
+	if len(settlement.Lines) != 0 {
+		t.Errorf("Settle(): expected empty batch, actual: %v", settlement.Lines)
+	}
+}
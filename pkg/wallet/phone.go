@@ -0,0 +1,95 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// CountryRule описывает номера одной страны: телефонный код и длину национального номера
+type CountryRule struct {
+	Country        string
+	Code           string
+	NationalLength int
+}
+
+// PhoneRules - список поддерживаемых стран и страна для номеров, записанных без кода
+type PhoneRules struct {
+	Countries      []CountryRule
+	DefaultCountry string
+}
+
+var DefaultPhoneRules = PhoneRules{
+	Countries: []CountryRule{
+		{Country: "TJ", Code: "992", NationalLength: 9},
+		{Country: "UZ", Code: "998", NationalLength: 9},
+		{Country: "KG", Code: "996", NationalLength: 9},
+		{Country: "RU", Code: "7", NationalLength: 10},
+	},
+	DefaultCountry: "TJ",
+}
+
+// Normalize приводит номер к виду E.164 (+992926421509).
+// Пробелы, дефисы, точки и скобки отбрасываются, префикс 00 равнозначен "+",
+// а номер без кода длины национального считается номером страны по умолчанию.
+func (r PhoneRules) Normalize(phone types.Phone) (types.Phone, error) {
+	str := strings.TrimSpace(string(phone))
+	international := false
+	if strings.HasPrefix(str, "+") {
+		international = true
+		str = str[1:]
+	}
+
+	digits := make([]byte, 0, len(str))
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c)
+		case c == ' ' || c == '-' || c == '.' || c == '(' || c == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	number := string(digits)
+	if !international && strings.HasPrefix(number, "00") {
+		international = true
+		number = number[2:]
+	}
+	// E.164 ограничивает номер пятнадцатью цифрами
+	if len(number) == 0 || len(number) > 15 {
+		return "", ErrInvalidPhone
+	}
+
+	if !international {
+		for _, rule := range r.Countries {
+			if rule.Country == r.DefaultCountry && len(number) == rule.NationalLength {
+				return types.Phone("+" + rule.Code + number), nil
+			}
+		}
+	}
+
+	for _, rule := range r.Countries {
+		if strings.HasPrefix(number, rule.Code) && len(number) == len(rule.Code)+rule.NationalLength {
+			return types.Phone("+" + number), nil
+		}
+	}
+
+	return "", ErrInvalidPhone
+}
+
+// SetPhoneRules задаёт правила проверки номеров вместо DefaultPhoneRules
+func (s *Service) SetPhoneRules(rules PhoneRules) {
+	s.phoneRules = &rules
+}
+
+func (s *Service) normalizePhone(phone types.Phone) (types.Phone, error) {
+	if s.phoneRules == nil {
+		return DefaultPhoneRules.Normalize(phone)
+	}
+	return s.phoneRules.Normalize(phone)
+}
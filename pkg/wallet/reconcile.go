@@ -0,0 +1,196 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrInvalidAdjustmentReason = errors.New("invalid adjustment reason")
+
+// RecordKind представляет собой вид записи, влияющей на баланс счёта
+type RecordKind string
+
+const (
+	RecordDeposit    RecordKind = "DEPOSIT"
+	RecordPayment    RecordKind = "PAYMENT"
+	RecordFee        RecordKind = "FEE"
+	RecordRefund     RecordKind = "REFUND"
+	RecordReject     RecordKind = "REJECT"
+	RecordTransferIn RecordKind = "TRANSFER_IN"
+	// RecordTransferBack - списание с получателя при возврате или отмене перевода
+	RecordTransferBack RecordKind = "TRANSFER_BACK"
+	RecordAdjustment   RecordKind = "ADJUSTMENT"
+)
+
+// BalanceRecord - запись истории и её вклад в баланс счёта (со знаком).
+// Category - категория платежа, к которому относится запись.
+type BalanceRecord struct {
+	Kind     RecordKind            `json:"kind"`
+	ID       string                `json:"id"`
+	Amount   types.Money           `json:"amount"`
+	Time     time.Time             `json:"time"`
+	Category types.PaymentCategory `json:"category,omitempty"`
+}
+
+// Discrepancy - расхождение баланса счёта с балансом, посчитанным по истории.
+// Difference = Balance - Expected.
+type Discrepancy struct {
+	AccountID  int64           `json:"account_id"`
+	Balance    types.Money     `json:"balance"`
+	Expected   types.Money     `json:"expected"`
+	Difference types.Money     `json:"difference"`
+	Records    []BalanceRecord `json:"records"`
+}
+
+// Reconcile пересчитывает баланс каждого счёта по пополнениям, платежам, возвратам,
+// переводам и корректировкам и возвращает счета, где он расходится с текущим
+func (s *Service) Reconcile() []Discrepancy {
+	var discrepancies []Discrepancy
+	for _, account := range s.accounts {
+		records := s.balanceRecords(account.ID)
+
+		expected := types.Money(0)
+		for _, record := range records {
+			expected += record.Amount
+		}
+
+		if expected != account.Balance {
+			discrepancies = append(discrepancies, Discrepancy{
+				AccountID:  account.ID,
+				Balance:    account.Balance,
+				Expected:   expected,
+				Difference: account.Balance - expected,
+				Records:    records,
+			})
+		}
+	}
+	return discrepancies
+}
+
+// AdjustDiscrepancies записывает по корректировке на каждое расхождение, после чего
+// история объясняет текущий баланс. Сами балансы не меняются: какая сторона верна, решает оператор.
+func (s *Service) AdjustDiscrepancies(discrepancies []Discrepancy, reason string) (_ []*types.Adjustment, rerr error) {
+	defer s.audited("AdjustDiscrepancies", auditArgs("count", len(discrepancies), "reason", reason))(&rerr)
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" || strings.ContainsAny(reason, ";\n") {
+		return nil, ErrInvalidAdjustmentReason
+	}
+
+	var adjustments []*types.Adjustment
+	for _, discrepancy := range discrepancies {
+		if _, err := s.FindAccountByID(discrepancy.AccountID); err != nil {
+			return adjustments, err
+		}
+		if discrepancy.Difference == 0 {
+			continue
+		}
+
+		id, err := s.newID()
+		if err != nil {
+			return adjustments, err
+		}
+		adjustment := &types.Adjustment{
+			ID:        id,
+			AccountID: discrepancy.AccountID,
+			Amount:    discrepancy.Difference,
+			Reason:    reason,
+			Time:      s.clock(),
+		}
+		s.adjustments = append(s.adjustments, adjustment)
+		adjustments = append(adjustments, adjustment)
+		s.publish(AccountAdjusted{Adjustment: *adjustment})
+	}
+	return adjustments, nil
+}
+
+// balanceRecords собирает все записи, влияющие на баланс счёта, в порядке времени.
+// Записи из старых дампов без времени идут первыми.
+func (s *Service) balanceRecords(accountID int64) []BalanceRecord {
+	var records []BalanceRecord
+
+	for _, deposit := range s.deposits {
+		if deposit.AccountID == accountID {
+			records = append(records, BalanceRecord{Kind: RecordDeposit, ID: deposit.ID, Amount: deposit.Amount, Time: deposit.Time})
+		}
+	}
+
+	for _, payment := range s.payments {
+		if payment.AccountID != accountID {
+			continue
+		}
+
+		records = append(records, BalanceRecord{Kind: RecordPayment, ID: payment.ID, Amount: -payment.Amount, Time: payment.Time, Category: payment.Category})
+		fee := s.feeFor(payment.ID)
+		if fee != nil {
+			records = append(records, BalanceRecord{Kind: RecordFee, ID: fee.ID, Amount: -fee.Amount, Time: payment.Time, Category: payment.Category})
+		}
+		for _, refund := range s.refunds {
+			if refund.PaymentID == payment.ID {
+				records = append(records, BalanceRecord{Kind: RecordRefund, ID: refund.ID, Amount: refund.Amount + refund.Fee, Time: refund.Time, Category: payment.Category})
+			}
+		}
+		// Reject возвращает остаток платежа после возвратов и остаток комиссии
+		if payment.Status == types.PaymentStatusFail {
+			amount := payment.Amount - s.refundedAmount(payment.ID)
+			if fee != nil {
+				amount += fee.Refunded - s.refundedFee(payment.ID)
+			}
+			records = append(records, BalanceRecord{Kind: RecordReject, ID: payment.ID, Amount: amount, Time: s.rejectionTime(payment.ID), Category: payment.Category})
+		}
+	}
+
+	for _, transfer := range s.transfers {
+		if transfer.ToAccountID != accountID {
+			continue
+		}
+		payment, err := s.FindPaymentByID(transfer.PaymentID)
+		if err != nil {
+			continue
+		}
+		// задержанный перевод зачисляется получателю только при одобрении
+		credited := payment.Time
+		review := s.reviewOf(payment.ID)
+		if payment.Status == types.PaymentStatusReview || review != nil && review.Decision == types.ReviewDeclined {
+			continue
+		}
+		if review != nil {
+			credited = review.Time
+		}
+
+		records = append(records, BalanceRecord{Kind: RecordTransferIn, ID: payment.ID, Amount: payment.Amount, Time: credited, Category: payment.Category})
+		// возвраты и отмена перевода списываются с получателя
+		for _, refund := range s.refunds {
+			if refund.PaymentID == payment.ID {
+				records = append(records, BalanceRecord{Kind: RecordTransferBack, ID: refund.ID, Amount: -refund.Amount, Time: refund.Time, Category: payment.Category})
+			}
+		}
+		if payment.Status == types.PaymentStatusFail {
+			amount := payment.Amount - s.refundedAmount(payment.ID)
+			records = append(records, BalanceRecord{Kind: RecordTransferBack, ID: payment.ID, Amount: -amount, Time: s.rejectionTime(payment.ID), Category: payment.Category})
+		}
+	}
+
+	for _, adjustment := range s.adjustments {
+		if adjustment.AccountID == accountID {
+			records = append(records, BalanceRecord{Kind: RecordAdjustment, ID: adjustment.ID, Amount: adjustment.Amount, Time: adjustment.Time})
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records
+}
+
+// rejectionTime возвращает время отмены платежа (нулевое для отмен из старых дампов)
+func (s *Service) rejectionTime(paymentID string) time.Time {
+	for _, rejection := range s.rejections {
+		if rejection.PaymentID == paymentID {
+			return rejection.Time
+		}
+	}
+	return time.Time{}
+}
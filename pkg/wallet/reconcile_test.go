@@ -0,0 +1,97 @@
+package wallet
+
+import (
+	"testing"
+)
+
+func TestService_Reconcile_success(t *testing.T) {
+	s := newTestService()
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	recipient, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payments[0].ID, 1_000)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+	err = s.Reject(payments[0].ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	transfer, err := s.Transfer(account.ID, string(recipient.Phone), 5_000)
+	if err != nil {
+		t.Errorf("Transfer(): error = %v", err)
+		return
+	}
+	_, err = s.Refund(transfer.ID, 2_000)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	discrepancies := s.Reconcile()
+	if len(discrepancies) != 0 {
+		t.Errorf("Reconcile(): expected no discrepancies, actual: %v", discrepancies)
+	}
+}
+
+func TestService_Reconcile_drift(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	account.Balance += 500
+
+	discrepancies := s.Reconcile()
+	if len(discrepancies) != 1 {
+		t.Errorf("Reconcile(): expected one discrepancy, actual: %v", discrepancies)
+		return
+	}
+	got := discrepancies[0]
+	if got.AccountID != account.ID || got.Difference != 500 || len(got.Records) != 2 {
+		t.Errorf("Reconcile(): wrong discrepancy = %v", got)
+		return
+	}
+
+	_, err = s.AdjustDiscrepancies(discrepancies, "")
+	if err != ErrInvalidAdjustmentReason {
+		t.Errorf("AdjustDiscrepancies(): wrong error = %v", err)
+		return
+	}
+	adjustments, err := s.AdjustDiscrepancies(discrepancies, "manual correction")
+	if err != nil {
+		t.Errorf("AdjustDiscrepancies(): error = %v", err)
+		return
+	}
+	if len(adjustments) != 1 || adjustments[0].Amount != 500 || account.Balance != got.Balance {
+		t.Errorf("AdjustDiscrepancies(): wrong adjustments = %v", adjustments)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := &Service{}
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	if discrepancies := imported.Reconcile(); len(discrepancies) != 0 {
+		t.Errorf("Reconcile(): expected no discrepancies after import, actual: %v", discrepancies)
+	}
+}
@@ -0,0 +1,136 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrPaymentInReview = errors.New("payment is waiting for review")
+var ErrPaymentNotInReview = errors.New("payment is not waiting for review")
+var ErrInvalidReview = errors.New("invalid reviewer or reason")
+
+// PendingReview - платёж, ждущий ручной проверки, и причины, по которым его задержали
+type PendingReview struct {
+	Payment types.Payment `json:"payment"`
+	Reasons []string      `json:"reasons"`
+}
+
+// PendingReviews возвращает очередь платежей, ждущих проверки, в порядке создания
+func (s *Service) PendingReviews() []PendingReview {
+	var reviews []PendingReview
+	for _, payment := range s.payments {
+		if payment.Status != types.PaymentStatusReview {
+			continue
+		}
+
+		review := PendingReview{Payment: *payment}
+		for _, event := range s.riskEvents {
+			if event.PaymentID == payment.ID {
+				review.Reasons = append(review.Reasons, event.Reasons...)
+			}
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews
+}
+
+// ApproveReview проводит задержанный платёж: статус меняется на PaymentStatusOk
+func (s *Service) ApproveReview(paymentID string, reviewer string, reason string) (rerr error) {
+	defer s.audited("ApproveReview", auditArgs("payment_id", paymentID, "reviewer", reviewer, "reason", reason))(&rerr)
+
+	payment, err := s.reviewedPayment(paymentID, reviewer, reason)
+	if err != nil {
+		return err
+	}
+
+	err = s.creditTransfer(payment)
+	if err != nil {
+		return err
+	}
+	err = s.creditMerchant(payment)
+	if err != nil {
+		return err
+	}
+	payment.Status = types.PaymentStatusOk
+	payment.Changed = s.nextSeq()
+	s.setCashbackStatus(payment.ID, types.CashbackConfirmed)
+	s.addReview(payment.ID, reviewer, types.ReviewApproved, reason)
+	s.publish(PaymentCompleted{Payment: *payment})
+	return nil
+}
+
+// DeclineReview отменяет задержанный платёж так же, как Reject, с возвратом средств на счёт
+func (s *Service) DeclineReview(paymentID string, reviewer string, reason string) (rerr error) {
+	defer s.audited("DeclineReview", auditArgs("payment_id", paymentID, "reviewer", reviewer, "reason", reason))(&rerr)
+
+	payment, err := s.reviewedPayment(paymentID, reviewer, reason)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.FindAccountByID(payment.AccountID)
+	if err != nil {
+		return err
+	}
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+	err = s.reject(payment, account)
+	if err != nil {
+		return err
+	}
+	s.addReview(payment.ID, reviewer, types.ReviewDeclined, reason)
+	return nil
+}
+
+// Reviews возвращает решения по платежу
+func (s *Service) Reviews(paymentID string) ([]types.Review, error) {
+	if _, err := s.FindPaymentByID(paymentID); err != nil {
+		return nil, err
+	}
+
+	var reviews []types.Review
+	for _, review := range s.reviews {
+		if review.PaymentID == paymentID {
+			reviews = append(reviews, *review)
+		}
+	}
+	return reviews, nil
+}
+
+// reviewOf возвращает решение по платежу или nil, если платёж не проверялся
+func (s *Service) reviewOf(paymentID string) *types.Review {
+	for _, review := range s.reviews {
+		if review.PaymentID == paymentID {
+			return review
+		}
+	}
+	return nil
+}
+
+func (s *Service) reviewedPayment(paymentID string, reviewer string, reason string) (*types.Payment, error) {
+	if strings.TrimSpace(reviewer) == "" || strings.ContainsAny(reviewer+reason, ";\n") {
+		return nil, ErrInvalidReview
+	}
+
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != types.PaymentStatusReview {
+		return nil, ErrPaymentNotInReview
+	}
+	return payment, nil
+}
+
+func (s *Service) addReview(paymentID string, reviewer string, decision types.ReviewDecision, reason string) {
+	s.reviews = append(s.reviews, &types.Review{
+		PaymentID: paymentID,
+		Reviewer:  strings.TrimSpace(reviewer),
+		Decision:  decision,
+		Reason:    reason,
+		Time:      s.clock(),
+	})
+}
@@ -0,0 +1,163 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func newReviewTestService(t *testing.T) (*testService, *types.Account, []*types.Payment) {
+	s := newTestService()
+	s.SetRiskEngine(NewRiskEngine(AmountAnomalyRule{Factor: 2, MinHistory: 1}))
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount(): error = %v", err)
+	}
+	err = s.Deposit(account.ID, 100_000)
+	if err != nil {
+		t.Fatalf("Deposit(): error = %v", err)
+	}
+
+	var payments []*types.Payment
+	for _, amount := range []types.Money{1_000, 10_000, 20_000} {
+		payment, err := s.Pay(account.ID, amount, "shop")
+		if err != nil {
+			t.Fatalf("Pay(): error = %v", err)
+		}
+		payments = append(payments, payment)
+	}
+	return s, account, payments
+}
+
+func TestService_ApproveReview_success(t *testing.T) {
+	s, _, payments := newReviewTestService(t)
+
+	reviews := s.PendingReviews()
+	if len(reviews) != 2 || reviews[0].Payment.ID != payments[1].ID || len(reviews[0].Reasons) != 1 {
+		t.Errorf("PendingReviews(): wrong queue = %v", reviews)
+		return
+	}
+
+	err := s.CompletePayment(payments[1].ID)
+	if err != ErrPaymentInReview {
+		t.Errorf("CompletePayment(): wrong error = %v", err)
+		return
+	}
+	err = s.ApproveReview(payments[1].ID, "", "ok")
+	if err != ErrInvalidReview {
+		t.Errorf("ApproveReview(): wrong error without reviewer = %v", err)
+		return
+	}
+	err = s.ApproveReview(payments[1].ID, "alice", "known customer")
+	if err != nil {
+		t.Errorf("ApproveReview(): error = %v", err)
+		return
+	}
+	if payments[1].Status != types.PaymentStatusOk {
+		t.Errorf("ApproveReview(): wrong status = %v", payments[1].Status)
+		return
+	}
+	err = s.ApproveReview(payments[1].ID, "alice", "again")
+	if err != ErrPaymentNotInReview {
+		t.Errorf("ApproveReview(): wrong error for reviewed payment = %v", err)
+		return
+	}
+
+	reviewed, err := s.Reviews(payments[1].ID)
+	if err != nil {
+		t.Errorf("Reviews(): error = %v", err)
+		return
+	}
+	if len(reviewed) != 1 || reviewed[0].Reviewer != "alice" || reviewed[0].Decision != types.ReviewApproved || reviewed[0].Reason != "known customer" {
+		t.Errorf("Reviews(): wrong reviews = %v", reviewed)
+	}
+}
+
+func TestService_DeclineReview_success(t *testing.T) {
+	s, account, payments := newReviewTestService(t)
+	balance := account.Balance
+
+	err := s.DeclineReview(payments[2].ID, "bob", "card stolen")
+	if err != nil {
+		t.Errorf("DeclineReview(): error = %v", err)
+		return
+	}
+	if payments[2].Status != types.PaymentStatusFail || account.Balance != balance+payments[2].Amount {
+		t.Errorf("DeclineReview(): payment not rejected, status = %v, balance = %d", payments[2].Status, account.Balance)
+		return
+	}
+	if reviews := s.PendingReviews(); len(reviews) != 1 {
+		t.Errorf("PendingReviews(): expected one review left, actual: %v", reviews)
+	}
+}
+
+func TestService_ApproveReview_merchant(t *testing.T) {
+	s := newTestService()
+	s.SetRiskEngine(NewRiskEngine(AmountAnomalyRule{Factor: 2, MinHistory: 1}))
+	merchant, err := s.RegisterMerchant("Shop")
+	if err != nil {
+		t.Errorf("RegisterMerchant(): error = %v", err)
+		return
+	}
+	err = s.AddCategory(types.Category{Code: "shop", Name: "Магазин", Enabled: true})
+	if err != nil {
+		t.Errorf("AddCategory(): error = %v", err)
+		return
+	}
+	err = s.AssignCategory(merchant.ID, "shop")
+	if err != nil {
+		t.Errorf("AssignCategory(): error = %v", err)
+		return
+	}
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 100_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 1_000, "shop")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	held, err := s.Pay(account.ID, 10_000, "shop")
+	if err != nil || held.Status != types.PaymentStatusReview {
+		t.Errorf("Pay(): payment must be held, payment = %v, error = %v", held, err)
+		return
+	}
+	if merchant.Balance != 1_000 {
+		t.Errorf("Pay(): held payment credited to merchant, balance = %d", merchant.Balance)
+		return
+	}
+
+	err = s.Reject(held.ID)
+	if err != ErrPaymentInReview {
+		t.Errorf("Reject(): wrong error for held payment = %v", err)
+		return
+	}
+	_, err = s.Refund(held.ID, 100)
+	if err != ErrPaymentInReview {
+		t.Errorf("Refund(): wrong error for held payment = %v", err)
+		return
+	}
+	settlement, err := s.Settle(s.clock().Add(time.Second))
+	if err != nil || len(settlement.Lines) != 1 || settlement.Lines[0].Net != 1_000 {
+		t.Errorf("Settle(): held payment must not be settled, settlement = %v, error = %v", settlement, err)
+		return
+	}
+
+	err = s.ApproveReview(held.ID, "alice", "known customer")
+	if err != nil {
+		t.Errorf("ApproveReview(): error = %v", err)
+		return
+	}
+	if merchant.Balance != 10_000 {
+		t.Errorf("ApproveReview(): approved payment not credited, balance = %d", merchant.Balance)
+	}
+}
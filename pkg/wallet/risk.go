@@ -0,0 +1,170 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrPaymentDenied = errors.New("payment denied by risk rules")
+
+// PaymentAttempt - попытка платежа, которую оценивают правила риска
+type PaymentAttempt struct {
+	AccountID int64
+	Amount    types.Money
+	Category  types.PaymentCategory
+	Time      time.Time
+}
+
+// RiskRule - правило проверки платежа. history - предыдущие пропущенные попытки
+// того же счёта, от старых к новым. RiskAllow означает, что правило не сработало.
+type RiskRule interface {
+	Evaluate(attempt PaymentAttempt, history []PaymentAttempt) (types.RiskDecision, string)
+}
+
+// RiskEngine проверяет платежи набором правил до списания средств.
+// Итоговое решение - самое строгое из решений правил (DENY, затем REVIEW).
+// Движок помнит последние HistorySize попыток каждого счёта, история не сохраняется в дампы.
+type RiskEngine struct {
+	Rules       []RiskRule
+	HistorySize int
+
+	history map[int64][]PaymentAttempt
+}
+
+func NewRiskEngine(rules ...RiskRule) *RiskEngine {
+	return &RiskEngine{
+		Rules:       rules,
+		HistorySize: 100,
+	}
+}
+
+// VelocityRule отклоняет платёж, если за последние Window со счёта уже было Max платежей
+type VelocityRule struct {
+	Max    int
+	Window time.Duration
+}
+
+func (r VelocityRule) Evaluate(attempt PaymentAttempt, history []PaymentAttempt) (types.RiskDecision, string) {
+	count := 0
+	for _, previous := range history {
+		if attempt.Time.Sub(previous.Time) < r.Window {
+			count++
+		}
+	}
+	if count >= r.Max {
+		return types.RiskDeny, fmt.Sprintf("more than %d payments in %s", r.Max, r.Window)
+	}
+	return types.RiskAllow, ""
+}
+
+// AmountAnomalyRule отправляет на проверку платёж больше Factor средних платежей счёта.
+// Пока у счёта меньше MinHistory платежей, правило не срабатывает.
+type AmountAnomalyRule struct {
+	Factor     int64
+	MinHistory int
+}
+
+func (r AmountAnomalyRule) Evaluate(attempt PaymentAttempt, history []PaymentAttempt) (types.RiskDecision, string) {
+	if len(history) == 0 || len(history) < r.MinHistory {
+		return types.RiskAllow, ""
+	}
+
+	sum := types.Money(0)
+	for _, previous := range history {
+		sum += previous.Amount
+	}
+	average := sum / types.Money(len(history))
+	if attempt.Amount > average*types.Money(r.Factor) {
+		return types.RiskReview, fmt.Sprintf("amount %d is more than %d times average %d", attempt.Amount, r.Factor, average)
+	}
+	return types.RiskAllow, ""
+}
+
+// BlockedCategoryRule отклоняет платежи в перечисленные категории
+type BlockedCategoryRule struct {
+	Categories []types.PaymentCategory
+}
+
+func (r BlockedCategoryRule) Evaluate(attempt PaymentAttempt, history []PaymentAttempt) (types.RiskDecision, string) {
+	for _, category := range r.Categories {
+		if category == attempt.Category {
+			return types.RiskDeny, fmt.Sprintf("category %s is blocked", category)
+		}
+	}
+	return types.RiskAllow, ""
+}
+
+// Evaluate применяет все правила к попытке и возвращает итоговое решение с причинами
+func (e *RiskEngine) Evaluate(attempt PaymentAttempt) (types.RiskDecision, []string) {
+	history := e.history[attempt.AccountID]
+
+	decision := types.RiskAllow
+	var reasons []string
+	for _, rule := range e.Rules {
+		d, reason := rule.Evaluate(attempt, history)
+		if d == types.RiskAllow {
+			continue
+		}
+		reasons = append(reasons, reason)
+		if d == types.RiskDeny || decision == types.RiskAllow {
+			decision = d
+		}
+	}
+	return decision, reasons
+}
+
+// remember добавляет пропущенную попытку в историю счёта
+func (e *RiskEngine) remember(attempt PaymentAttempt) {
+	if e.history == nil {
+		e.history = make(map[int64][]PaymentAttempt)
+	}
+
+	history := append(e.history[attempt.AccountID], attempt)
+	if e.HistorySize > 0 && len(history) > e.HistorySize {
+		history = history[len(history)-e.HistorySize:]
+	}
+	e.history[attempt.AccountID] = history
+}
+
+// SetRiskEngine включает проверку платежей в Pay (nil - выключает)
+func (s *Service) SetRiskEngine(engine *RiskEngine) {
+	s.risk = engine
+}
+
+// RiskEvents возвращает отклонённые и отправленные на проверку попытки платежей
+func (s *Service) RiskEvents() []types.RiskEvent {
+	events := make([]types.RiskEvent, 0, len(s.riskEvents))
+	for _, event := range s.riskEvents {
+		events = append(events, *event)
+	}
+	return events
+}
+
+// checkRisk оценивает попытку платежа; без движка любой платёж разрешён
+func (s *Service) checkRisk(attempt PaymentAttempt) (types.RiskDecision, []string) {
+	if s.risk == nil {
+		return types.RiskAllow, nil
+	}
+	return s.risk.Evaluate(attempt)
+}
+
+func (s *Service) recordRisk(attempt PaymentAttempt, decision types.RiskDecision, reasons []string, paymentID string) error {
+	id, err := s.newID()
+	if err != nil {
+		return err
+	}
+	s.riskEvents = append(s.riskEvents, &types.RiskEvent{
+		ID:        id,
+		AccountID: attempt.AccountID,
+		Amount:    attempt.Amount,
+		Category:  attempt.Category,
+		Time:      attempt.Time,
+		Decision:  decision,
+		Reasons:   reasons,
+		PaymentID: paymentID,
+	})
+	return nil
+}
@@ -0,0 +1,129 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func TestService_Pay_risk(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)}
+	s.SetClock(clock.Now)
+	s.SetRiskEngine(NewRiskEngine(
+		VelocityRule{Max: 3, Window: time.Minute},
+		AmountAnomalyRule{Factor: 10, MinHistory: 2},
+		BlockedCategoryRule{Categories: []types.PaymentCategory{"casino"}},
+	))
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 1_000_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "casino")
+	if err != ErrPaymentDenied {
+		t.Errorf("Pay(): blocked category not denied, error = %v", err)
+		return
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err = s.Pay(account.ID, 100, "food")
+		if err != nil {
+			t.Errorf("Pay(): error = %v", err)
+			return
+		}
+	}
+	_, err = s.Pay(account.ID, 100, "food")
+	if err != ErrPaymentDenied {
+		t.Errorf("Pay(): velocity limit not applied, error = %v", err)
+		return
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	payment, err := s.Pay(account.ID, 5_000, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	events := s.RiskEvents()
+	if len(events) != 3 {
+		t.Errorf("RiskEvents(): expected 3 events, actual: %v", events)
+		return
+	}
+	if events[0].Decision != types.RiskDeny || events[1].Decision != types.RiskDeny || events[0].PaymentID != "" {
+		t.Errorf("RiskEvents(): wrong denied events = %v", events[:2])
+		return
+	}
+	if events[2].Decision != types.RiskReview || events[2].PaymentID != payment.ID || len(events[2].Reasons) != 1 {
+		t.Errorf("RiskEvents(): wrong review event = %v", events[2])
+	}
+}
+
+func TestService_Transfer_risk(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)}
+	s.SetClock(clock.Now)
+	s.SetRiskEngine(NewRiskEngine(
+		VelocityRule{Max: 2, Window: time.Minute},
+		AmountAnomalyRule{Factor: 10, MinHistory: 1},
+	))
+
+	from, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	to, err := s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(from.ID, 1_000_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(from.ID, 100, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	held, err := s.Transfer(from.ID, "+992000000002", 5_000)
+	if err != nil || held.Status != types.PaymentStatusReview {
+		t.Errorf("Transfer(): transfer must be held, payment = %v, error = %v", held, err)
+		return
+	}
+	if to.Balance != 0 {
+		t.Errorf("Transfer(): held transfer credited, balance = %d", to.Balance)
+		return
+	}
+	_, err = s.Transfer(from.ID, "+992000000002", 100)
+	if err != ErrPaymentDenied {
+		t.Errorf("Transfer(): velocity limit not applied, error = %v", err)
+		return
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	err = s.ApproveReview(held.ID, "alice", "known recipient")
+	if err != nil {
+		t.Errorf("ApproveReview(): error = %v", err)
+		return
+	}
+	if to.Balance != 5_000 {
+		t.Errorf("ApproveReview(): transfer not credited, balance = %d", to.Balance)
+		return
+	}
+	if discrepancies := s.Reconcile(); len(discrepancies) != 0 {
+		t.Errorf("Reconcile(): wrong discrepancies = %v", discrepancies)
+	}
+}
@@ -0,0 +1,252 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrScheduleNotFound = errors.New("schedule not found")
+var ErrInvalidInterval = errors.New("invalid schedule interval")
+
+// ScheduleFavorite создаёт регулярный платёж по избранному, первый запуск - в момент start
+func (s *Service) ScheduleFavorite(favoriteID string, interval types.ScheduleInterval, cron string, start time.Time) (_ *types.Schedule, rerr error) {
+	defer s.audited("ScheduleFavorite", auditArgs("favorite_id", favoriteID, "interval", interval, "cron", cron, "start", start.Format(time.RFC3339)))(&rerr)
+
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch interval {
+	case types.ScheduleDaily, types.ScheduleWeekly, types.ScheduleMonthly:
+		cron = ""
+	case types.ScheduleCron:
+		spec, err := parseCron(cron)
+		if err != nil {
+			return nil, err
+		}
+		// первый запуск - ближайшее подходящее под выражение время, не раньше start
+		start = spec.next(start.Add(-time.Minute))
+		if start.IsZero() {
+			return nil, ErrInvalidCron
+		}
+	default:
+		return nil, ErrInvalidInterval
+	}
+
+	id, err := s.newID()
+	if err != nil {
+		return nil, err
+	}
+	schedule := &types.Schedule{
+		ID:         id,
+		FavoriteID: favorite.ID,
+		Interval:   interval,
+		Cron:       cron,
+		NextRun:    start,
+		Anchor:     start,
+		Active:     true,
+	}
+	s.schedules = append(s.schedules, schedule)
+	s.publish(ScheduleCreated{Schedule: *schedule})
+	return schedule, nil
+}
+
+func (s *Service) FindScheduleByID(scheduleID string) (*types.Schedule, error) {
+	for _, schedule := range s.schedules {
+		if schedule.ID == scheduleID {
+			return schedule, nil
+		}
+	}
+
+	return nil, ErrScheduleNotFound
+}
+
+// CancelSchedule отключает регулярный платёж, история запусков сохраняется
+func (s *Service) CancelSchedule(scheduleID string) (rerr error) {
+	defer s.audited("CancelSchedule", auditArgs("schedule_id", scheduleID))(&rerr)
+
+	schedule, err := s.FindScheduleByID(scheduleID)
+	if err != nil {
+		return err
+	}
+
+	schedule.Active = false
+	s.publish(ScheduleCanceled{Schedule: *schedule})
+	return nil
+}
+
+// ScheduleHistory возвращает все запуски регулярного платежа в порядке выполнения
+func (s *Service) ScheduleHistory(scheduleID string) ([]types.ScheduleRun, error) {
+	if _, err := s.FindScheduleByID(scheduleID); err != nil {
+		return nil, err
+	}
+
+	var runs []types.ScheduleRun
+	for _, run := range s.scheduleRuns {
+		if run.ScheduleID == scheduleID {
+			runs = append(runs, *run)
+		}
+	}
+	return runs, nil
+}
+
+// Scheduler выполняет наступившие регулярные платежи сервиса.
+// Service не потокобезопасен: если с ним параллельно работает сервер, в Lock нужно
+// передать тот же мьютекс, под которым сервер вызывает Service.
+type Scheduler struct {
+	svc *Service
+	now func() time.Time
+
+	// Lock захватывается на время RunDue; nil - сервис используется только планировщиком
+	Lock sync.Locker
+
+	// MaxRetries - сколько раз повторять платёж при нехватке средств
+	MaxRetries int
+	// Backoff - задержка перед первым повтором, каждый следующий ждёт вдвое дольше
+	Backoff time.Duration
+}
+
+// NewScheduler создаёт планировщик, now позволяет подменить часы (nil - time.Now)
+func NewScheduler(svc *Service, now func() time.Time) *Scheduler {
+	if now == nil {
+		now = time.Now
+	}
+	return &Scheduler{
+		svc:        svc,
+		now:        now,
+		MaxRetries: 3,
+		Backoff:    time.Hour,
+	}
+}
+
+// RunDue выполняет все наступившие платежи и возвращает результаты запусков
+func (sc *Scheduler) RunDue() []types.ScheduleRun {
+	if sc.Lock != nil {
+		sc.Lock.Lock()
+		defer sc.Lock.Unlock()
+	}
+	now := sc.now()
+
+	// платежи по расписанию выполняет сам сервис, а не пользователь
+	actor := sc.svc.actor
+	sc.svc.SetActor("scheduler")
+	defer sc.svc.SetActor(actor)
+
+	var runs []types.ScheduleRun
+	for _, schedule := range sc.svc.schedules {
+		if !schedule.Active || !scheduleDue(schedule, now) {
+			continue
+		}
+		run := sc.execute(schedule, now)
+		sc.svc.scheduleRuns = append(sc.svc.scheduleRuns, &run)
+		sc.svc.publish(ScheduleRan{Run: run})
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// Run вызывает RunDue каждые interval, пока не отменён ctx
+func (sc *Scheduler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		sc.RunDue()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (sc *Scheduler) execute(schedule *types.Schedule, now time.Time) types.ScheduleRun {
+	run := types.ScheduleRun{
+		ScheduleID: schedule.ID,
+		Time:       now,
+	}
+
+	payment, err := sc.svc.PayFromFavorite(schedule.FavoriteID)
+	if err == nil {
+		run.PaymentID = payment.ID
+		run.Status = types.ScheduleRunOk
+		advanceSchedule(schedule, now)
+		return run
+	}
+
+	run.Error = err.Error()
+	if err == ErrNotEnoughBalance && schedule.Attempts < sc.MaxRetries {
+		run.Status = types.ScheduleRunRetry
+		schedule.RetryAt = now.Add(sc.Backoff << schedule.Attempts)
+		schedule.Attempts++
+		return run
+	}
+
+	run.Status = types.ScheduleRunFail
+	advanceSchedule(schedule, now)
+	return run
+}
+
+func scheduleDue(schedule *types.Schedule, now time.Time) bool {
+	if !schedule.RetryAt.IsZero() {
+		return !now.Before(schedule.RetryAt)
+	}
+	return !now.Before(schedule.NextRun)
+}
+
+// advanceSchedule переносит следующий запуск за now: пропущенные, пока планировщик
+// не работал, запуски не выполняются пачкой
+func advanceSchedule(schedule *types.Schedule, now time.Time) {
+	schedule.Attempts = 0
+	schedule.RetryAt = time.Time{}
+
+	for !schedule.NextRun.After(now) {
+		next := nextRun(schedule)
+		if next.IsZero() {
+			schedule.Active = false
+			return
+		}
+		schedule.NextRun = next
+	}
+}
+
+func nextRun(schedule *types.Schedule) time.Time {
+	switch schedule.Interval {
+	case types.ScheduleDaily:
+		return schedule.NextRun.AddDate(0, 0, 1)
+	case types.ScheduleWeekly:
+		return schedule.NextRun.AddDate(0, 0, 7)
+	case types.ScheduleMonthly:
+		// запуск отсчитывается от дня первого запуска, а не от предыдущего:
+		// иначе платёж 31 января после 2 марта уходил бы на 2-е число навсегда
+		anchor := schedule.Anchor
+		if anchor.IsZero() {
+			anchor = schedule.NextRun
+		}
+		months := (schedule.NextRun.Year()-anchor.Year())*12 + int(schedule.NextRun.Month()-anchor.Month())
+		return addMonths(anchor, months+1)
+	case types.ScheduleCron:
+		spec, err := parseCron(schedule.Cron)
+		if err != nil {
+			return time.Time{}
+		}
+		return spec.next(schedule.NextRun)
+	}
+	return time.Time{}
+}
+
+// addMonths сдвигает t на months месяцев; день, которого нет в месяце, заменяется последним днём
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
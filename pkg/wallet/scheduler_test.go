@@ -0,0 +1,202 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestCron_next(t *testing.T) {
+	spec, err := parseCron("30 9 * * 1")
+	if err != nil {
+		t.Errorf("parseCron(): error = %v", err)
+		return
+	}
+
+	// 2021-03-03 - среда, ближайший понедельник - 2021-03-08
+	got := spec.next(time.Date(2021, 3, 3, 12, 0, 0, 0, time.UTC))
+	want := time.Date(2021, 3, 8, 9, 30, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("next(): expected: %v, actual: %v", want, got)
+	}
+
+	_, err = parseCron("61 * * * *")
+	if err != ErrInvalidCron {
+		t.Errorf("parseCron(): must return ErrInvalidCron, returned = %v", err)
+	}
+}
+
+func TestScheduler_RunDue_success(t *testing.T) {
+	s := newTestService()
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	favorite, err := s.FavoritePayment(payments[0].ID, "internet")
+	if err != nil {
+		t.Errorf("FavoritePayment(): error = %v", err)
+		return
+	}
+
+	clock := &testClock{now: time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)}
+	schedule, err := s.ScheduleFavorite(favorite.ID, types.ScheduleDaily, "", clock.now)
+	if err != nil {
+		t.Errorf("ScheduleFavorite(): error = %v", err)
+		return
+	}
+
+	scheduler := NewScheduler(s.Service, clock.Now)
+	runs := scheduler.RunDue()
+	if len(runs) != 1 || runs[0].Status != types.ScheduleRunOk {
+		t.Errorf("RunDue(): expected one successful run, actual: %v", runs)
+		return
+	}
+
+	runs = scheduler.RunDue()
+	if len(runs) != 0 {
+		t.Errorf("RunDue(): next run must wait a day, actual: %v", runs)
+		return
+	}
+
+	clock.now = clock.now.AddDate(0, 0, 1)
+	scheduler.RunDue()
+
+	history, err := s.ScheduleHistory(schedule.ID)
+	if err != nil {
+		t.Errorf("ScheduleHistory(): error = %v", err)
+		return
+	}
+	if len(history) != 2 {
+		t.Errorf("ScheduleHistory(): expected 2 runs, actual: %v", history)
+	}
+}
+
+func TestScheduler_RunDue_retry(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992926421505", 100_00)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	payment, err := s.Pay(account.ID, 100_00, "internet")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	favorite, err := s.FavoritePayment(payment.ID, "internet")
+	if err != nil {
+		t.Errorf("FavoritePayment(): error = %v", err)
+		return
+	}
+
+	clock := &testClock{now: time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)}
+	schedule, err := s.ScheduleFavorite(favorite.ID, types.ScheduleMonthly, "", clock.now)
+	if err != nil {
+		t.Errorf("ScheduleFavorite(): error = %v", err)
+		return
+	}
+
+	scheduler := NewScheduler(s.Service, clock.Now)
+	scheduler.MaxRetries = 1
+	runs := scheduler.RunDue()
+	if len(runs) != 1 || runs[0].Status != types.ScheduleRunRetry {
+		t.Errorf("RunDue(): expected retry, actual: %v", runs)
+		return
+	}
+	if !schedule.RetryAt.Equal(clock.now.Add(scheduler.Backoff)) {
+		t.Errorf("RunDue(): wrong retry time: %v", schedule.RetryAt)
+		return
+	}
+
+	clock.now = schedule.RetryAt
+	runs = scheduler.RunDue()
+	if len(runs) != 1 || runs[0].Status != types.ScheduleRunFail {
+		t.Errorf("RunDue(): expected fail after retries, actual: %v", runs)
+		return
+	}
+	if !schedule.NextRun.Equal(time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("RunDue(): wrong next run: %v", schedule.NextRun)
+	}
+}
+
+// countingLocker считает захваты, чтобы проверить, что планировщик берёт общий мьютекс
+type countingLocker struct {
+	locked int
+}
+
+func (l *countingLocker) Lock()   { l.locked++ }
+func (l *countingLocker) Unlock() {}
+
+func TestScheduler_RunDue_monthly(t *testing.T) {
+	s := newTestService()
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	favorite, err := s.FavoritePayment(payments[0].ID, "internet")
+	if err != nil {
+		t.Errorf("FavoritePayment(): error = %v", err)
+		return
+	}
+
+	clock := &testClock{now: time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)}
+	schedule, err := s.ScheduleFavorite(favorite.ID, types.ScheduleMonthly, "", clock.now)
+	if err != nil {
+		t.Errorf("ScheduleFavorite(): error = %v", err)
+		return
+	}
+
+	lock := &countingLocker{}
+	scheduler := NewScheduler(s.Service, clock.Now)
+	scheduler.Lock = lock
+	// день запуска не съезжает после короткого месяца
+	for _, want := range []time.Time{
+		time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 30, 10, 0, 0, 0, time.UTC),
+	} {
+		runs := scheduler.RunDue()
+		if len(runs) != 1 || !schedule.NextRun.Equal(want) {
+			t.Errorf("RunDue(): wrong next run = %v, expected: %v, runs = %v", schedule.NextRun, want, runs)
+			return
+		}
+		clock.now = schedule.NextRun
+	}
+	if lock.locked != 3 {
+		t.Errorf("RunDue(): Lock taken %d times, expected 3", lock.locked)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := &Service{}
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	got, err := imported.FindScheduleByID(schedule.ID)
+	if err != nil || !got.NextRun.Equal(schedule.NextRun) || !got.Anchor.Equal(schedule.Anchor) || !got.Active {
+		t.Errorf("Import(): schedule not restored = %v, error = %v", got, err)
+		return
+	}
+	history, err := imported.ScheduleHistory(schedule.ID)
+	if err != nil || len(history) != 3 || history[2].Status != types.ScheduleRunOk {
+		t.Errorf("Import(): schedule runs not restored = %v, error = %v", history, err)
+	}
+}
@@ -0,0 +1,118 @@
+package wallet
+
+import (
+	"sort"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+// SeqRecord - платёж, пополнение или избранное под общим порядковым номером; заполнено одно из полей.
+// Seq - номер создания записи или её последнего изменения, если она менялась.
+// Deleted - избранное удалено, в Favorite его последнее состояние.
+type SeqRecord struct {
+	Seq      int64           `json:"seq"`
+	Payment  *types.Payment  `json:"payment,omitempty"`
+	Deposit  *types.Deposit  `json:"deposit,omitempty"`
+	Favorite *types.Favorite `json:"favorite,omitempty"`
+	Deleted  bool            `json:"deleted,omitempty"`
+}
+
+// LastSeq возвращает последний выданный порядковый номер
+func (s *Service) LastSeq() int64 {
+	return s.seq
+}
+
+// Since возвращает не больше limit записей, созданных, изменённых или удалённых после cursor,
+// в порядке номеров (limit <= 0 - без ограничения). Изменённая запись отдаётся целиком
+// под номером последнего изменения. Номер последней записи - курсор для следующего вызова.
+func (s *Service) Since(cursor int64, limit int) []SeqRecord {
+	records := []SeqRecord{}
+	for _, payment := range s.payments {
+		if seq := lastSeq(payment.Seq, payment.Changed); seq > cursor {
+			copied := *payment
+			records = append(records, SeqRecord{Seq: seq, Payment: &copied})
+		}
+	}
+	for _, deposit := range s.deposits {
+		if deposit.Seq > cursor {
+			copied := *deposit
+			records = append(records, SeqRecord{Seq: deposit.Seq, Deposit: &copied})
+		}
+	}
+	for _, favorite := range s.favorites {
+		if seq := lastSeq(favorite.Seq, favorite.Changed); seq > cursor {
+			copied := *favorite
+			records = append(records, SeqRecord{Seq: seq, Favorite: &copied})
+		}
+	}
+	for _, favorite := range s.deleted {
+		if favorite.Changed > cursor {
+			copied := *favorite
+			records = append(records, SeqRecord{Seq: favorite.Changed, Favorite: &copied, Deleted: true})
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Seq < records[j].Seq })
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records
+}
+
+// lastSeq возвращает номер последнего изменения записи, а без изменений - номер создания
+func lastSeq(seq int64, changed int64) int64 {
+	if changed > seq {
+		return changed
+	}
+	return seq
+}
+
+func (s *Service) nextSeq() int64 {
+	s.seq++
+	return s.seq
+}
+
+// resequence продолжает счётчик после загруженных номеров, выдаёт номера записям
+// из старых дампов в порядке загрузки и упорядочивает записи по номерам
+func (s *Service) resequence() {
+	for _, payment := range s.payments {
+		if seq := lastSeq(payment.Seq, payment.Changed); seq > s.seq {
+			s.seq = seq
+		}
+	}
+	for _, deposit := range s.deposits {
+		if deposit.Seq > s.seq {
+			s.seq = deposit.Seq
+		}
+	}
+	for _, favorite := range s.favorites {
+		if seq := lastSeq(favorite.Seq, favorite.Changed); seq > s.seq {
+			s.seq = seq
+		}
+	}
+	for _, favorite := range s.deleted {
+		if seq := lastSeq(favorite.Seq, favorite.Changed); seq > s.seq {
+			s.seq = seq
+		}
+	}
+
+	for _, payment := range s.payments {
+		if payment.Seq == 0 {
+			payment.Seq = s.nextSeq()
+		}
+	}
+	for _, deposit := range s.deposits {
+		if deposit.Seq == 0 {
+			deposit.Seq = s.nextSeq()
+		}
+	}
+	for _, favorite := range s.favorites {
+		if favorite.Seq == 0 {
+			favorite.Seq = s.nextSeq()
+		}
+	}
+
+	sort.SliceStable(s.payments, func(i, j int) bool { return s.payments[i].Seq < s.payments[j].Seq })
+	sort.SliceStable(s.deposits, func(i, j int) bool { return s.deposits[i].Seq < s.deposits[j].Seq })
+	sort.SliceStable(s.favorites, func(i, j int) bool { return s.favorites[i].Seq < s.favorites[j].Seq })
+}
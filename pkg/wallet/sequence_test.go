@@ -0,0 +1,163 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+func TestService_Since_success(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 10_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	payment, err := s.Pay(account.ID, 1_000, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	favorite, err := s.FavoritePayment(payment.ID, "lunch")
+	if err != nil {
+		t.Errorf("FavoritePayment(): error = %v", err)
+		return
+	}
+	if s.LastSeq() != 3 || payment.Seq != 2 || favorite.Seq != 3 {
+		t.Errorf("wrong sequence numbers, last = %d, payment = %d, favorite = %d", s.LastSeq(), payment.Seq, favorite.Seq)
+		return
+	}
+
+	records := s.Since(0, 2)
+	if len(records) != 2 || records[0].Deposit == nil || records[1].Payment == nil || records[1].Payment.ID != payment.ID {
+		t.Errorf("Since(): wrong first page = %v", records)
+		return
+	}
+	records = s.Since(records[1].Seq, 2)
+	if len(records) != 1 || records[0].Favorite == nil || records[0].Seq != 3 {
+		t.Errorf("Since(): wrong second page = %v", records)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := &Service{}
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	if imported.LastSeq() != 3 || imported.payments[0].Seq != 2 || imported.deposits[0].Seq != 1 || imported.favorites[0].Seq != 3 {
+		t.Errorf("Import(): sequence numbers not preserved, last = %d", imported.LastSeq())
+		return
+	}
+	next, err := imported.Pay(account.ID, 100, "food")
+	if err != nil || next.Seq != 4 {
+		t.Errorf("Pay(): wrong sequence after import = %v, error = %v", next, err)
+	}
+}
+
+func TestService_Since_changes(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 10_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	payment, err := s.Pay(account.ID, 1_000, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	favorite, err := s.FavoritePayment(payment.ID, "lunch")
+	if err != nil {
+		t.Errorf("FavoritePayment(): error = %v", err)
+		return
+	}
+	cursor := s.LastSeq()
+
+	// изменения и удаление получают новые номера и видны по курсору
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	_, err = s.UpdateFavorite(favorite.ID, "dinner", 1_000)
+	if err != nil {
+		t.Errorf("UpdateFavorite(): error = %v", err)
+		return
+	}
+	err = s.DeleteFavorite(favorite.ID)
+	if err != nil {
+		t.Errorf("DeleteFavorite(): error = %v", err)
+		return
+	}
+
+	records := s.Since(cursor, 0)
+	if len(records) != 2 || records[0].Payment == nil || records[0].Payment.Status != types.PaymentStatusFail ||
+		records[1].Favorite == nil || !records[1].Deleted || records[1].Favorite.Name != "dinner" || records[1].Seq != s.LastSeq() {
+		t.Errorf("Since(): wrong changes = %v", records)
+		return
+	}
+	if payment.Seq != 2 {
+		t.Errorf("Reject(): creation sequence changed = %d", payment.Seq)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := &Service{}
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	if imported.LastSeq() != s.LastSeq() || len(imported.Since(cursor, 0)) != 2 {
+		t.Errorf("Import(): changes not preserved, last = %d, records = %v", imported.LastSeq(), imported.Since(cursor, 0))
+	}
+}
+
+func TestService_Import_legacySeq(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "payments.dump"), []byte("new;1;100;food;OK;;7\nold1;1;200;food;OK\nold2;1;300;food;OK\n"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Service{}
+	err = s.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	// платежи без номеров получают номера после загруженных и встают после них
+	var ids []string
+	var seqs []int64
+	for _, payment := range s.payments {
+		ids = append(ids, payment.ID)
+		seqs = append(seqs, payment.Seq)
+	}
+	if len(ids) != 3 || ids[0] != "new" || ids[1] != "old1" || seqs[1] != 8 || seqs[2] != 9 || s.LastSeq() != 9 {
+		t.Errorf("Import(): wrong order = %v, seqs = %v", ids, seqs)
+	}
+}
@@ -6,13 +6,13 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Habibullo-1999/wallet/pkg/types"
-	"github.com/google/uuid"
-
 )
 
 var ErrPhoneRegistered = errors.New("phone already registered")
@@ -22,28 +22,79 @@ var ErrNotEnoughBalance = errors.New("not enough balance")
 var ErrPaymentNotFound = errors.New("payment not found")
 var ErrFavoriteNotFound = errors.New("favorite not found")
 var ErrFileNotFound = errors.New("File not found")
+var ErrPaymentRejected = errors.New("payment already rejected")
+var ErrPaymentCompleted = errors.New("payment already completed")
+var ErrRefundExceedsPayment = errors.New("refund amount exceeds payment amount")
+var ErrFavoriteNameTaken = errors.New("favorite name already used")
+var ErrInvalidFavoriteName = errors.New("invalid favorite name")
+var ErrAccountFrozen = errors.New("account is frozen")
+var ErrAccountClosed = errors.New("account is closed")
+var ErrAccountNotFrozen = errors.New("account is not frozen")
+var ErrAccountHasBalance = errors.New("account balance must be zero to close")
+var ErrFeeNotFound = errors.New("fee not found")
 
 type Service struct {
-	nextAccountID int64
-	accounts      []*types.Account
-	payments      []*types.Payment
-	favorites     []*types.Favorite
+	nextAccountID   int64
+	seq             int64
+	ids             IDGenerator
+	usedIDs         map[string]struct{}
+	accounts        []*types.Account
+	payments        []*types.Payment
+	favorites       []*types.Favorite
+	deleted         []*types.Favorite
+	phoneRules      *PhoneRules
+	refunds         []*types.Refund
+	schedules       []*types.Schedule
+	scheduleRuns    []*types.ScheduleRun
+	identifiers     []*types.Identifier
+	phoneChanges    []*types.PhoneChange
+	transfers       []*types.Transfer
+	deposits        []*types.Deposit
+	adjustments     []*types.Adjustment
+	feeSchedule     FeeSchedule
+	fees            []*types.Fee
+	cashback        CashbackProgram
+	cashbacks       []*types.Cashback
+	categories      []*types.Category
+	merchants       []*types.Merchant
+	merchantEntries []*types.MerchantEntry
+	risk            *RiskEngine
+	riskEvents      []*types.RiskEvent
+	reviews         []*types.Review
+	rejections      []*types.Rejection
+	now             func() time.Time
+	events          eventBus
+	audit           *AuditLog
+	actor           string
+	auditDepth      int
 }
 
-func (s *Service) RegisterAccount(phone types.Phone) (*types.Account, error) {
+func (s *Service) RegisterAccount(phone types.Phone) (_ *types.Account, rerr error) {
+	defer s.audited("RegisterAccount", auditArgs("phone", phone))(&rerr)
+
+	phone, err := s.normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, account := range s.accounts {
 		if account.Phone == phone {
 			return nil, ErrPhoneRegistered
 		}
 	}
 
-	s.nextAccountID++
+	id, err := s.newAccountID()
+	if err != nil {
+		return nil, err
+	}
 	account := &types.Account{
-		ID:      s.nextAccountID,
+		ID:      id,
 		Phone:   phone,
 		Balance: 0,
+		Status:  types.AccountStatusActive,
 	}
 	s.accounts = append(s.accounts, account)
+	s.publish(AccountRegistered{Account: *account})
 
 	return account, nil
 }
@@ -58,7 +109,9 @@ func (s *Service) FindAccountByID(accountID int64) (*types.Account, error) {
 	return nil, ErrAccountNotFound
 }
 
-func (s *Service) Deposit(accountID int64, amount types.Money) error {
+func (s *Service) Deposit(accountID int64, amount types.Money) (rerr error) {
+	defer s.audited("Deposit", auditArgs("account_id", accountID, "amount", amount))(&rerr)
+
 	if amount <= 0 {
 		return ErrAmountMustBePositive
 	}
@@ -67,13 +120,31 @@ func (s *Service) Deposit(accountID int64, amount types.Money) error {
 	if err != nil {
 		return ErrAccountNotFound
 	}
+	err = checkAccountActive(account)
+	if err != nil {
+		return err
+	}
 
-	// зачисление средств пока не рассматриваем как платёж
+	// зачисление средств не платёж, но записывается, чтобы баланс можно было сверить с историей
+	id, err := s.newID()
+	if err != nil {
+		return err
+	}
 	account.Balance += amount
+	s.deposits = append(s.deposits, &types.Deposit{
+		ID:        id,
+		Seq:       s.nextSeq(),
+		AccountID: account.ID,
+		Amount:    amount,
+		Time:      s.clock(),
+	})
+	s.publish(Deposited{AccountID: account.ID, Amount: amount, Balance: account.Balance})
 	return nil
 }
 
-func (s *Service) Pay(accountID int64, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
+func (s *Service) Pay(accountID int64, amount types.Money, category types.PaymentCategory) (_ *types.Payment, rerr error) {
+	defer s.audited("Pay", auditArgs("account_id", accountID, "amount", amount, "category", category))(&rerr)
+
 	if amount <= 0 {
 		return nil, ErrAmountMustBePositive
 	}
@@ -89,23 +160,197 @@ func (s *Service) Pay(accountID int64, amount types.Money, category types.Paymen
 		return nil, ErrAccountNotFound
 	}
 
-	if account.Balance < amount {
+	err := checkAccountActive(account)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkCategory(category)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt := PaymentAttempt{AccountID: account.ID, Amount: amount, Category: category, Time: s.clock()}
+	decision, reasons := s.checkRisk(attempt)
+	if decision == types.RiskDeny {
+		if err := s.recordRisk(attempt, decision, reasons, ""); err != nil {
+			return nil, err
+		}
+		return nil, ErrPaymentDenied
+	}
+
+	// подозрительный платёж списывается, но ждёт ручной проверки
+	status := types.PaymentStatusInProgress
+	if decision == types.RiskReview {
+		status = types.PaymentStatusReview
+	}
+
+	payment, err := s.pay(account, amount, category, s.feeSchedule.Fee(account.ID, amount, category), status)
+	if err != nil {
+		return nil, err
+	}
+	if s.risk != nil {
+		s.risk.remember(attempt)
+	}
+	if decision == types.RiskReview {
+		if err := s.recordRisk(attempt, decision, reasons, payment.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.accrueCashback(payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// pay списывает со счёта amount и комиссию fee и создаёт платёж со статусом status,
+// комиссия записывается отдельно от платежа
+func (s *Service) pay(account *types.Account, amount types.Money, category types.PaymentCategory, fee types.Money, status types.PaymentStatus) (*types.Payment, error) {
+	if account.Balance < amount+fee {
 		return nil, ErrNotEnoughBalance
 	}
 
-	account.Balance -= amount
-	paymentID := uuid.New().String()
+	paymentID, err := s.newID()
+	if err != nil {
+		return nil, err
+	}
+	account.Balance -= amount + fee
 	payment := &types.Payment{
 		ID:        paymentID,
-		AccountID: accountID,
+		Seq:       s.nextSeq(),
+		AccountID: account.ID,
 		Amount:    amount,
 		Category:  category,
-		Status:    types.PaymentStatusInProgress,
+		Status:    status,
+		Time:      s.clock(),
 	}
 	s.payments = append(s.payments, payment)
+	if err := s.addFee(payment, fee); err != nil {
+		return nil, err
+	}
+	// задержанный платёж зачисляется мерчанту только после одобрения
+	if status != types.PaymentStatusReview {
+		if err := s.creditMerchant(payment); err != nil {
+			return nil, err
+		}
+	}
+	s.publish(PaymentCreated{Payment: *payment})
+	return payment, nil
+}
+
+// checkAccountActive возвращает ошибку, если по счёту нельзя проводить операции.
+// Пустой статус (счета из старых дампов) считается активным.
+func checkAccountActive(account *types.Account) error {
+	switch account.Status {
+	case types.AccountStatusFrozen:
+		return ErrAccountFrozen
+	case types.AccountStatusClosed:
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// FreezeAccount блокирует все операции по счёту до вызова UnfreezeAccount
+func (s *Service) FreezeAccount(accountID int64) (rerr error) {
+	defer s.audited("FreezeAccount", auditArgs("account_id", accountID))(&rerr)
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	err = checkAccountActive(account)
+	if err != nil {
+		return err
+	}
+
+	s.setAccountStatus(account, types.AccountStatusFrozen)
+	return nil
+}
+
+func (s *Service) UnfreezeAccount(accountID int64) (rerr error) {
+	defer s.audited("UnfreezeAccount", auditArgs("account_id", accountID))(&rerr)
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Status != types.AccountStatusFrozen {
+		return ErrAccountNotFrozen
+	}
+
+	s.setAccountStatus(account, types.AccountStatusActive)
+	return nil
+}
+
+// CloseAccount закрывает активный или замороженный счёт с нулевым балансом
+func (s *Service) CloseAccount(accountID int64) (rerr error) {
+	defer s.audited("CloseAccount", auditArgs("account_id", accountID))(&rerr)
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+	if account.Balance != 0 {
+		return ErrAccountHasBalance
+	}
+
+	s.setAccountStatus(account, types.AccountStatusClosed)
+	return nil
+}
+
+// PayoutAndClose выводит весь остаток платежом в категорию category и закрывает счёт.
+// Работает и для замороженного счёта.
+func (s *Service) PayoutAndClose(accountID int64, category types.PaymentCategory) (_ *types.Payment, rerr error) {
+	defer s.audited("PayoutAndClose", auditArgs("account_id", accountID, "category", category))(&rerr)
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Status == types.AccountStatusClosed {
+		return nil, ErrAccountClosed
+	}
+
+	var payment *types.Payment
+	if account.Balance > 0 {
+		err = s.checkCategory(category)
+		if err != nil {
+			return nil, err
+		}
+		payment, err = s.pay(account, account.Balance, category, 0, types.PaymentStatusInProgress)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.setAccountStatus(account, types.AccountStatusClosed)
 	return payment, nil
 }
 
+// SetClock подменяет часы сервиса (nil - time.Now)
+func (s *Service) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+func (s *Service) clock() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+func (s *Service) setAccountStatus(account *types.Account, status types.AccountStatus) {
+	from := account.Status
+	account.Status = status
+	s.publish(AccountStatusChanged{AccountID: account.ID, From: from, To: status})
+}
+
 func (s *Service) FindPaymentByID(paymentID string) (*types.Payment, error) {
 	for _, payment := range s.payments {
 		if payment.ID == paymentID {
@@ -116,7 +361,34 @@ func (s *Service) FindPaymentByID(paymentID string) (*types.Payment, error) {
 	return nil, ErrPaymentNotFound
 }
 
-func (s *Service) Reject(paymentID string) error {
+// CompletePayment подтверждает проведённый платёж: статус меняется на PaymentStatusOk
+func (s *Service) CompletePayment(paymentID string) (rerr error) {
+	defer s.audited("CompletePayment", auditArgs("payment_id", paymentID))(&rerr)
+
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return err
+	}
+
+	switch payment.Status {
+	case types.PaymentStatusFail:
+		return ErrPaymentRejected
+	case types.PaymentStatusOk:
+		return ErrPaymentCompleted
+	case types.PaymentStatusReview:
+		return ErrPaymentInReview
+	}
+
+	payment.Status = types.PaymentStatusOk
+	payment.Changed = s.nextSeq()
+	s.setCashbackStatus(payment.ID, types.CashbackConfirmed)
+	s.publish(PaymentCompleted{Payment: *payment})
+	return nil
+}
+
+func (s *Service) Reject(paymentID string) (rerr error) {
+	defer s.audited("Reject", auditArgs("payment_id", paymentID))(&rerr)
+
 	payment, err := s.FindPaymentByID(paymentID)
 	if err != nil {
 		return err
@@ -126,28 +398,180 @@ func (s *Service) Reject(paymentID string) error {
 		return err
 	}
 
+	switch payment.Status {
+	case types.PaymentStatusFail:
+		return ErrPaymentRejected
+	case types.PaymentStatusReview:
+		// задержанный платёж отменяется через DeclineReview, чтобы решение попало в очередь проверки
+		return ErrPaymentInReview
+	}
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+
+	return s.reject(payment, account)
+}
+
+// reject отменяет платёж и возвращает на счёт ещё не возвращённую часть вместе с комиссией
+func (s *Service) reject(payment *types.Payment, account *types.Account) error {
+	// часть платежа могла быть уже возвращена через Refund
+	amount := payment.Amount - s.refundedAmount(payment.ID)
+	// задержанный перевод ещё не зачислен получателю
+	if payment.Status != types.PaymentStatusReview {
+		err := s.reverseTransfer(payment.ID, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.debitMerchant(payment, amount)
+	if err != nil {
+		return err
+	}
+	// отменённый платёж возвращается целиком, поэтому возвращается и весь остаток комиссии
+	amount += s.refundFee(payment, payment.Amount)
+
 	payment.Status = types.PaymentStatusFail
-	account.Balance += payment.Amount
+	payment.Changed = s.nextSeq()
+	account.Balance += amount
+	s.rejections = append(s.rejections, &types.Rejection{
+		PaymentID: payment.ID,
+		AccountID: account.ID,
+		Amount:    amount,
+		Time:      s.clock(),
+	})
+	s.setCashbackStatus(payment.ID, types.CashbackReversed)
+	s.publish(PaymentRejected{Payment: *payment, Amount: amount})
 	return nil
 }
 
-func (s *Service) Repeat(paymentID string) (*types.Payment, error) {
+// Refund возвращает на счёт часть платежа, не больше ещё не возвращённой суммы,
+// вместе с пропорциональной частью комиссии
+func (s *Service) Refund(paymentID string, amount types.Money) (_ *types.Refund, rerr error) {
+	defer s.audited("Refund", auditArgs("payment_id", paymentID, "amount", amount))(&rerr)
+
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	switch payment.Status {
+	case types.PaymentStatusFail:
+		return nil, ErrPaymentRejected
+	case types.PaymentStatusReview:
+		return nil, ErrPaymentInReview
+	}
+
+	account, err := s.FindAccountByID(payment.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Status == types.AccountStatusClosed {
+		return nil, ErrAccountClosed
+	}
+
+	if s.refundedAmount(payment.ID)+amount > payment.Amount {
+		return nil, ErrRefundExceedsPayment
+	}
+
+	id, err := s.newID()
+	if err != nil {
+		return nil, err
+	}
+	err = s.reverseTransfer(payment.ID, amount)
+	if err != nil {
+		return nil, err
+	}
+	err = s.debitMerchant(payment, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// комиссия возвращается пропорционально всей возвращённой части платежа
+	fee := s.refundFee(payment, s.refundedAmount(payment.ID)+amount)
+	account.Balance += amount + fee
+	refund := &types.Refund{
+		ID:        id,
+		PaymentID: payment.ID,
+		AccountID: payment.AccountID,
+		Amount:    amount,
+		Fee:       fee,
+		Time:      s.clock(),
+	}
+	s.refunds = append(s.refunds, refund)
+	payment.Changed = s.nextSeq()
+	s.reduceCashback(payment)
+	s.publish(PaymentRefunded{Refund: *refund})
+	return refund, nil
+}
+
+// RefundedAmount возвращает сумму, уже возвращённую по платежу
+func (s *Service) RefundedAmount(paymentID string) (types.Money, error) {
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.refundedAmount(payment.ID), nil
+}
+
+func (s *Service) refundedAmount(paymentID string) types.Money {
+	sum := types.Money(0)
+	for _, refund := range s.refunds {
+		if refund.PaymentID == paymentID {
+			sum += refund.Amount
+		}
+	}
+	return sum
+}
+
+func (s *Service) Repeat(paymentID string) (_ *types.Payment, rerr error) {
+	defer s.audited("Repeat", auditArgs("payment_id", paymentID))(&rerr)
+
 	payment, err := s.FindPaymentByID(paymentID)
 	if err != nil {
 		return nil, err
 	}
 
+	// перевод повторяется тому же получателю, иначе деньги спишутся и никуда не поступят
+	transfer := s.findTransfer(payment.ID)
+	if transfer != nil {
+		recipient, err := s.FindAccountByID(transfer.ToAccountID)
+		if err != nil {
+			return nil, err
+		}
+		return s.Transfer(payment.AccountID, string(recipient.Phone), payment.Amount)
+	}
+
 	return s.Pay(payment.AccountID, payment.Amount, payment.Category)
 }
 
-func (s *Service) FavoritePayment(paymentID string, name string) (*types.Favorite, error) {
+func (s *Service) FavoritePayment(paymentID string, name string) (_ *types.Favorite, rerr error) {
+	defer s.audited("FavoritePayment", auditArgs("payment_id", paymentID, "name", name))(&rerr)
+
 	payment, err := s.FindPaymentByID(paymentID)
 	if err != nil {
 		return nil, err
 	}
+	if s.findTransfer(payment.ID) != nil {
+		return nil, ErrTransferFavorite
+	}
+
+	err = s.validateFavoriteName(payment.AccountID, "", name)
+	if err != nil {
+		return nil, err
+	}
 
+	id, err := s.newID()
+	if err != nil {
+		return nil, err
+	}
 	favorite := &types.Favorite{
-		ID:        uuid.New().String(),
+		ID:        id,
+		Seq:       s.nextSeq(),
 		AccountID: payment.AccountID,
 		Amount:    payment.Amount,
 		Name:      name,
@@ -155,9 +579,89 @@ func (s *Service) FavoritePayment(paymentID string, name string) (*types.Favorit
 	}
 
 	s.favorites = append(s.favorites, favorite)
+	s.publish(FavoriteCreated{Favorite: *favorite})
+	return favorite, nil
+}
+
+// validateFavoriteName проверяет, что имя непустое, пишется в дамп и не занято другим избранным счёта
+func (s *Service) validateFavoriteName(accountID int64, favoriteID string, name string) error {
+	if strings.TrimSpace(name) == "" || strings.ContainsAny(name, ";\n") {
+		return ErrInvalidFavoriteName
+	}
+
+	for _, favorite := range s.favorites {
+		if favorite.AccountID == accountID && favorite.ID != favoriteID && favorite.Name == name {
+			return ErrFavoriteNameTaken
+		}
+	}
+	return nil
+}
+
+// FavoritesByAccount возвращает все избранные платежи счёта
+func (s *Service) FavoritesByAccount(accountID int64) ([]types.Favorite, error) {
+	_, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var favorites []types.Favorite
+	for _, favorite := range s.favorites {
+		if favorite.AccountID == accountID {
+			favorites = append(favorites, *favorite)
+		}
+	}
+	return favorites, nil
+}
+
+// UpdateFavorite меняет имя и сумму избранного
+func (s *Service) UpdateFavorite(favoriteID string, name string, amount types.Money) (_ *types.Favorite, rerr error) {
+	defer s.audited("UpdateFavorite", auditArgs("favorite_id", favoriteID, "name", name, "amount", amount))(&rerr)
+
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
+	}
+
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.validateFavoriteName(favorite.AccountID, favorite.ID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	favorite.Name = name
+	favorite.Amount = amount
+	favorite.Changed = s.nextSeq()
+	s.publish(FavoriteUpdated{Favorite: *favorite})
 	return favorite, nil
 }
 
+// DeleteFavorite удаляет избранное и отключает привязанные к нему регулярные платежи.
+// Удалённое избранное остаётся в Since с новым номером, чтобы удаление увидели по курсору.
+func (s *Service) DeleteFavorite(favoriteID string) (rerr error) {
+	defer s.audited("DeleteFavorite", auditArgs("favorite_id", favoriteID))(&rerr)
+
+	for i, favorite := range s.favorites {
+		if favorite.ID == favoriteID {
+			s.favorites = append(s.favorites[:i], s.favorites[i+1:]...)
+			favorite.Changed = s.nextSeq()
+			s.deleted = append(s.deleted, favorite)
+
+			for _, schedule := range s.schedules {
+				if schedule.FavoriteID == favoriteID {
+					schedule.Active = false
+				}
+			}
+			s.publish(FavoriteDeleted{Favorite: *favorite})
+			return nil
+		}
+	}
+
+	return ErrFavoriteNotFound
+}
+
 func (s *Service) FindFavoriteByID(favoriteID string) (*types.Favorite, error) {
 	for _, favorite := range s.favorites {
 		if favorite.ID == favoriteID {
@@ -168,11 +672,17 @@ func (s *Service) FindFavoriteByID(favoriteID string) (*types.Favorite, error) {
 	return nil, ErrFavoriteNotFound
 }
 
-func (s *Service) PayFromFavorite(favoriteID string) (*types.Payment, error) {
+func (s *Service) PayFromFavorite(favoriteID string) (_ *types.Payment, rerr error) {
+	defer s.audited("PayFromFavorite", auditArgs("favorite_id", favoriteID))(&rerr)
+
 	favorite, err := s.FindFavoriteByID(favoriteID)
 	if err != nil {
 		return nil, err
 	}
+	// избранное не хранит получателя, а платёж без зачисления потерял бы деньги
+	if favorite.Category == TransferCategory {
+		return nil, ErrTransferFavorite
+	}
 
 	return s.Pay(favorite.AccountID, favorite.Amount, favorite.Category)
 }
@@ -204,121 +714,1071 @@ func (s *Service) ExportToFile(path string) error {
 	return nil
 }
 
-func (s *Service) ImportFromFile(path string) error {
+func (s *Service) ImportFromFile(path string) (rerr error) {
+	defer s.audited("ImportFromFile", auditArgs("path", path))(&rerr)
+
 	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		err := file.Close()
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			log.Print(err)
+		}
+	}()
+
+	content := make([]byte, 0)
+	buf := make([]byte, 4)
+
+	for {
+		read, err := file.Read(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		content = append(content, buf[:read]...)
+	}
+	accounts := strings.Split(string(content), "|")
+	accounts = accounts[:len(accounts)-1]
+
+	for _, acc := range accounts {
+		splits := strings.Split(acc, ";")
+
+		id, err := strconv.Atoi(splits[0])
+		if err != nil {
+			return err
+		}
+
+		balance, err := strconv.Atoi(splits[2])
+		if err != nil {
+			return err
+		}
+
+		phone, err := s.normalizePhone(types.Phone(splits[1]))
+		if err != nil {
+			return err
+		}
+
+		account := &types.Account{
+			ID:      int64(id),
+			Phone:   phone,
+			Balance: types.Money(balance),
+			Status:  types.AccountStatusActive,
+		}
+
+		s.accounts = append(s.accounts, account)
+		if account.ID > s.nextAccountID {
+			s.nextAccountID = account.ID
+		}
+	}
+	return nil
+}
+
+// Export выгружает состояние в dir, создавая его при необходимости. Каждый файл дампа
+// перезаписывается, даже если записей нет, чтобы удалённые записи не вернулись при следующем Import.
+func (s *Service) Export(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	str := ""
+	for _, v := range s.accounts {
+		str += fmt.Sprint(v.ID) + ";" + string(v.Phone) + ";" + fmt.Sprint(v.Balance) + ";" + string(v.Status) + "\n"
+	}
+	err = writeDump(dir, "accounts.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.payments {
+		str += fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + fmt.Sprint(v.Category) + ";" + fmt.Sprint(v.Status) + ";" + formatDumpTime(v.Time) + ";" + fmt.Sprint(v.Seq) + ";" + fmt.Sprint(v.Changed) + "\n"
+	}
+	err = writeDump(dir, "payments.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.favorites {
+		str += fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + fmt.Sprint(v.Category) + ";" + v.Name + ";" + fmt.Sprint(v.Seq) + ";" + fmt.Sprint(v.Changed) + "\n"
+	}
+	err = writeDump(dir, "favorites.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.refunds {
+		str += fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.PaymentID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + formatDumpTime(v.Time) + ";" + fmt.Sprint(v.Fee) + "\n"
+	}
+	err = writeDump(dir, "refunds.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.identifiers {
+		str += fmt.Sprint(v.AccountID) + ";" + string(v.Kind) + ";" + v.Value + "\n"
+	}
+	err = writeDump(dir, "identifiers.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.transfers {
+		str += v.PaymentID + ";" + fmt.Sprint(v.ToAccountID) + "\n"
+	}
+	err = writeDump(dir, "transfers.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.deposits {
+		str += v.ID + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + formatDumpTime(v.Time) + ";" + fmt.Sprint(v.Seq) + "\n"
+	}
+	err = writeDump(dir, "deposits.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.adjustments {
+		str += v.ID + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + v.Reason + ";" + formatDumpTime(v.Time) + "\n"
+	}
+	err = writeDump(dir, "adjustments.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.rejections {
+		str += v.PaymentID + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + formatDumpTime(v.Time) + "\n"
+	}
+	err = writeDump(dir, "rejections.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.fees {
+		str += v.ID + ";" + v.PaymentID + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + fmt.Sprint(v.Refunded) + "\n"
+	}
+	err = writeDump(dir, "fees.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.cashbacks {
+		str += v.ID + ";" + v.PaymentID + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + string(v.Status) + ";" + v.Time.Format(time.RFC3339Nano) + ";" + fmt.Sprint(v.Refunded) + "\n"
+	}
+	err = writeDump(dir, "cashbacks.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.categories {
+		str += string(v.Code) + ";" + v.Name + ";" + string(v.Parent) + ";" + strconv.FormatBool(v.Enabled) + ";" + v.Merchant + "\n"
+	}
+	err = writeDump(dir, "categories.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.merchants {
+		str += v.ID + ";" + v.Name + ";" + fmt.Sprint(v.Balance) + "\n"
+	}
+	err = writeDump(dir, "merchants.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.merchantEntries {
+		str += v.ID + ";" + v.MerchantID + ";" + v.PaymentID + ";" + fmt.Sprint(v.Amount) + ";" + v.Time.Format(time.RFC3339Nano) + ";" + v.BatchID + "\n"
+	}
+	err = writeDump(dir, "merchant_entries.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.riskEvents {
+		str += v.ID + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + string(v.Category) + ";" + v.Time.Format(time.RFC3339Nano) + ";" + string(v.Decision) + ";" + v.PaymentID + ";" + strings.Join(v.Reasons, "|") + "\n"
+	}
+	err = writeDump(dir, "risk_events.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.reviews {
+		str += v.PaymentID + ";" + v.Reviewer + ";" + string(v.Decision) + ";" + v.Time.Format(time.RFC3339Nano) + ";" + v.Reason + "\n"
+	}
+	err = writeDump(dir, "reviews.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.phoneChanges {
+		str += fmt.Sprint(v.AccountID) + ";" + string(v.OldPhone) + ";" + string(v.NewPhone) + ";" + v.Time.Format(time.RFC3339Nano) + "\n"
+	}
+	err = writeDump(dir, "phone_changes.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.schedules {
+		str += v.ID + ";" + v.FavoriteID + ";" + string(v.Interval) + ";" + v.Cron + ";" + v.NextRun.Format(time.RFC3339Nano) + ";" + formatDumpTime(v.RetryAt) + ";" + fmt.Sprint(v.Attempts) + ";" + strconv.FormatBool(v.Active) + ";" + formatDumpTime(v.Anchor) + "\n"
+	}
+	err = writeDump(dir, "schedules.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.scheduleRuns {
+		// текст ошибки не должен ломать формат дампа
+		message := strings.NewReplacer(";", ",", "\n", " ").Replace(v.Error)
+		str += v.ScheduleID + ";" + v.PaymentID + ";" + v.Time.Format(time.RFC3339Nano) + ";" + string(v.Status) + ";" + message + "\n"
+	}
+	err = writeDump(dir, "schedule_runs.dump", str)
+	if err != nil {
+		return err
+	}
+
+	str = ""
+	for _, v := range s.deleted {
+		str += fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + fmt.Sprint(v.Category) + ";" + v.Name + ";" + fmt.Sprint(v.Seq) + ";" + fmt.Sprint(v.Changed) + "\n"
+	}
+	err = writeDump(dir, "deleted_favorites.dump", str)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeDump перезаписывает файл дампа name в dir
+func writeDump(dir string, name string, content string) error {
+	return os.WriteFile(filepath.Join(dir, name), []byte(content), 0666)
+}
+
+func (s *Service) Import(dir string) (rerr error) {
+	defer s.audited("Import", auditArgs("dir", dir))(&rerr)
+
+	_, err := os.Stat(dir + "/accounts.dump")
+
+	if err == nil {
+		content, err := os.ReadFile(dir + "/accounts.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrAcount := strings.Split(v, ";")
+
+			id, err := strconv.ParseInt(strArrAcount[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			balance, err := strconv.ParseInt(strArrAcount[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			phone, err := s.normalizePhone(types.Phone(strArrAcount[1]))
+			if err != nil {
+				return err
+			}
+			// в старых дампах статуса нет, такие счета считаются активными
+			status := types.AccountStatusActive
+			if len(strArrAcount) > 3 && strArrAcount[3] != "" {
+				status = types.AccountStatus(strArrAcount[3])
+			}
+			// в старых дампах один номер мог быть записан в разном формате под разными ID
+			for _, v := range s.accounts {
+				if v.ID != id && v.Phone == phone {
+					return ErrPhoneRegistered
+				}
+			}
+			flag := true
+			for _, v := range s.accounts {
+				if v.ID == id {
+					v.Phone = phone
+					v.Balance = types.Money(balance)
+					v.Status = status
+					flag = false
+				}
+			}
+			if flag {
+				account := &types.Account{
+					ID:      id,
+					Phone:   phone,
+					Balance: types.Money(balance),
+					Status:  status,
+				}
+				s.accounts = append(s.accounts, account)
+			}
+			// новые счета не должны получить ID уже загруженных
+			if id > s.nextAccountID {
+				s.nextAccountID = id
+			}
+		}
+	}
+
+	_, err1 := os.Stat(dir + "/payments.dump")
+
+	if err1 == nil {
+		content, err := os.ReadFile(dir + "/payments.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrAcount := strings.Split(v, ";")
+
+			id := strArrAcount[0]
+			if err != nil {
+				return err
+			}
+			aid, err := strconv.ParseInt(strArrAcount[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(strArrAcount[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			created, err := parseDumpTime(strArrAcount, 5)
+			if err != nil {
+				return err
+			}
+			seq, err := parseDumpSeq(strArrAcount, 6)
+			if err != nil {
+				return err
+			}
+			changed, err := parseDumpSeq(strArrAcount, 7)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.payments {
+				if v.ID == id {
+					v.AccountID = aid
+					v.Amount = types.Money(amount)
+					v.Category = types.PaymentCategory(strArrAcount[3])
+					v.Status = types.PaymentStatus(strArrAcount[4])
+					v.Time = created
+					v.Seq = seq
+					v.Changed = changed
+					flag = false
+				}
+			}
+			if flag {
+				data := &types.Payment{
+					ID:        id,
+					Seq:       seq,
+					Changed:   changed,
+					AccountID: aid,
+					Amount:    types.Money(amount),
+					Category:  types.PaymentCategory(strArrAcount[3]),
+					Status:    types.PaymentStatus(strArrAcount[4]),
+					Time:      created,
+				}
+				s.payments = append(s.payments, data)
+			}
+		}
+	}
+
+	_, err2 := os.Stat(dir + "/favorites.dump")
+
+	if err2 == nil {
+		content, err := os.ReadFile(dir + "/favorites.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrAcount := strings.Split(v, ";")
+
+			id := strArrAcount[0]
+			if err != nil {
+				return err
+			}
+			aid, err := strconv.ParseInt(strArrAcount[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(strArrAcount[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			// в старых дампах имени избранного нет
+			name := ""
+			if len(strArrAcount) > 4 {
+				name = strArrAcount[4]
+			}
+			seq, err := parseDumpSeq(strArrAcount, 5)
+			if err != nil {
+				return err
+			}
+			changed, err := parseDumpSeq(strArrAcount, 6)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.favorites {
+				if v.ID == id {
+					v.AccountID = aid
+					v.Amount = types.Money(amount)
+					v.Category = types.PaymentCategory(strArrAcount[3])
+					v.Name = name
+					v.Seq = seq
+					v.Changed = changed
+					flag = false
+				}
+			}
+			if flag {
+				data := &types.Favorite{
+					ID:        id,
+					Seq:       seq,
+					Changed:   changed,
+					AccountID: aid,
+					Amount:    types.Money(amount),
+					Category:  types.PaymentCategory(strArrAcount[3]),
+					Name:      name,
+				}
+				s.favorites = append(s.favorites, data)
+			}
+		}
+	}
+
+	_, err3 := os.Stat(dir + "/refunds.dump")
+
+	if err3 == nil {
+		content, err := os.ReadFile(dir + "/refunds.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrRefund := strings.Split(v, ";")
+
+			id := strArrRefund[0]
+			aid, err := strconv.ParseInt(strArrRefund[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(strArrRefund[3], 10, 64)
+			if err != nil {
+				return err
+			}
+			created, err := parseDumpTime(strArrRefund, 4)
+			if err != nil {
+				return err
+			}
+			fee, err := parseDumpMoney(strArrRefund, 5)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.refunds {
+				if v.ID == id {
+					v.PaymentID = strArrRefund[1]
+					v.AccountID = aid
+					v.Amount = types.Money(amount)
+					v.Fee = fee
+					v.Time = created
+					flag = false
+				}
+			}
+			if flag {
+				data := &types.Refund{
+					ID:        id,
+					PaymentID: strArrRefund[1],
+					AccountID: aid,
+					Amount:    types.Money(amount),
+					Fee:       fee,
+					Time:      created,
+				}
+				s.refunds = append(s.refunds, data)
+			}
+		}
+	}
+
+	_, err4 := os.Stat(dir + "/identifiers.dump")
+
+	if err4 == nil {
+		content, err := os.ReadFile(dir + "/identifiers.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrIdentifier := strings.Split(v, ";")
+
+			aid, err := strconv.ParseInt(strArrIdentifier[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			kind := types.IdentifierKind(strArrIdentifier[1])
+			flag := true
+			for _, v := range s.identifiers {
+				if v.Kind == kind && v.Value == strArrIdentifier[2] {
+					v.AccountID = aid
+					flag = false
+				}
+			}
+			if flag {
+				data := &types.Identifier{
+					AccountID: aid,
+					Kind:      kind,
+					Value:     strArrIdentifier[2],
+				}
+				s.identifiers = append(s.identifiers, data)
+			}
+		}
+	}
+
+	_, err5 := os.Stat(dir + "/transfers.dump")
+
+	if err5 == nil {
+		content, err := os.ReadFile(dir + "/transfers.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrTransfer := strings.Split(v, ";")
+
+			aid, err := strconv.ParseInt(strArrTransfer[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.transfers {
+				if v.PaymentID == strArrTransfer[0] {
+					v.ToAccountID = aid
+					flag = false
+				}
+			}
+			if flag {
+				data := &types.Transfer{
+					PaymentID:   strArrTransfer[0],
+					ToAccountID: aid,
+				}
+				s.transfers = append(s.transfers, data)
+			}
+		}
+	}
+
+	_, err6 := os.Stat(dir + "/deposits.dump")
+
+	if err6 == nil {
+		content, err := os.ReadFile(dir + "/deposits.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrDeposit := strings.Split(v, ";")
+
+			aid, err := strconv.ParseInt(strArrDeposit[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(strArrDeposit[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			created, err := parseDumpTime(strArrDeposit, 3)
+			if err != nil {
+				return err
+			}
+			seq, err := parseDumpSeq(strArrDeposit, 4)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.deposits {
+				if v.ID == strArrDeposit[0] {
+					v.AccountID = aid
+					v.Amount = types.Money(amount)
+					v.Time = created
+					v.Seq = seq
+					flag = false
+				}
+			}
+			if flag {
+				data := &types.Deposit{
+					ID:        strArrDeposit[0],
+					Seq:       seq,
+					AccountID: aid,
+					Amount:    types.Money(amount),
+					Time:      created,
+				}
+				s.deposits = append(s.deposits, data)
+			}
+		}
+	}
+
+	_, err7 := os.Stat(dir + "/adjustments.dump")
+
+	if err7 == nil {
+		content, err := os.ReadFile(dir + "/adjustments.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrAdjustment := strings.Split(v, ";")
+
+			aid, err := strconv.ParseInt(strArrAdjustment[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(strArrAdjustment[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			created, err := parseDumpTime(strArrAdjustment, 4)
+			if err != nil {
+				return err
+			}
+			reason := ""
+			if len(strArrAdjustment) > 3 {
+				reason = strArrAdjustment[3]
+			}
+			flag := true
+			for _, v := range s.adjustments {
+				if v.ID == strArrAdjustment[0] {
+					v.AccountID = aid
+					v.Amount = types.Money(amount)
+					v.Reason = reason
+					v.Time = created
+					flag = false
+				}
+			}
+			if flag {
+				data := &types.Adjustment{
+					ID:        strArrAdjustment[0],
+					AccountID: aid,
+					Amount:    types.Money(amount),
+					Reason:    reason,
+					Time:      created,
+				}
+				s.adjustments = append(s.adjustments, data)
+			}
+		}
+	}
+
+	_, err8 := os.Stat(dir + "/fees.dump")
+
+	if err8 == nil {
+		content, err := os.ReadFile(dir + "/fees.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrFee := strings.Split(v, ";")
+
+			aid, err := strconv.ParseInt(strArrFee[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(strArrFee[3], 10, 64)
+			if err != nil {
+				return err
+			}
+			refunded, err := strconv.ParseInt(strArrFee[4], 10, 64)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.fees {
+				if v.ID == strArrFee[0] {
+					v.PaymentID = strArrFee[1]
+					v.AccountID = aid
+					v.Amount = types.Money(amount)
+					v.Refunded = types.Money(refunded)
+					flag = false
+				}
+			}
+			if flag {
+				data := &types.Fee{
+					ID:        strArrFee[0],
+					PaymentID: strArrFee[1],
+					AccountID: aid,
+					Amount:    types.Money(amount),
+					Refunded:  types.Money(refunded),
+				}
+				s.fees = append(s.fees, data)
+			}
+		}
+	}
+
+	_, err9 := os.Stat(dir + "/cashbacks.dump")
+
+	if err9 == nil {
+		content, err := os.ReadFile(dir + "/cashbacks.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrCashback := strings.Split(v, ";")
+
+			aid, err := strconv.ParseInt(strArrCashback[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(strArrCashback[3], 10, 64)
+			if err != nil {
+				return err
+			}
+			accrued, err := time.Parse(time.RFC3339Nano, strArrCashback[5])
+			if err != nil {
+				return err
+			}
+			refunded, err := parseDumpMoney(strArrCashback, 6)
+			if err != nil {
+				return err
+			}
+			status := types.CashbackStatus(strArrCashback[4])
+			flag := true
+			for _, v := range s.cashbacks {
+				if v.ID == strArrCashback[0] {
+					v.PaymentID = strArrCashback[1]
+					v.AccountID = aid
+					v.Amount = types.Money(amount)
+					v.Refunded = refunded
+					v.Status = status
+					v.Time = accrued
+					flag = false
+				}
+			}
+			if flag {
+				data := &types.Cashback{
+					ID:        strArrCashback[0],
+					PaymentID: strArrCashback[1],
+					AccountID: aid,
+					Amount:    types.Money(amount),
+					Refunded:  refunded,
+					Status:    status,
+					Time:      accrued,
+				}
+				s.cashbacks = append(s.cashbacks, data)
+			}
+		}
+	}
+
+	_, err10 := os.Stat(dir + "/categories.dump")
+
+	if err10 == nil {
+		content, err := os.ReadFile(dir + "/categories.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrCategory := strings.Split(v, ";")
+
+			enabled, err := strconv.ParseBool(strArrCategory[3])
+			if err != nil {
+				return err
+			}
+			data := types.Category{
+				Code:     types.PaymentCategory(strArrCategory[0]),
+				Name:     strArrCategory[1],
+				Parent:   types.PaymentCategory(strArrCategory[2]),
+				Enabled:  enabled,
+				Merchant: strArrCategory[4],
+			}
+			category, err := s.FindCategory(data.Code)
+			if err == nil {
+				*category = data
+			} else {
+				s.categories = append(s.categories, &data)
+			}
+		}
+	}
+
+	_, err11 := os.Stat(dir + "/merchants.dump")
+
+	if err11 == nil {
+		content, err := os.ReadFile(dir + "/merchants.dump")
 		if err != nil {
-			log.Print(err)
+			return err
 		}
-	}()
 
-	content := make([]byte, 0)
-	buf := make([]byte, 4)
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrMerchant := strings.Split(v, ";")
 
-	for {
-		read, err := file.Read(buf)
-		if err == io.EOF {
-			break
+			balance, err := strconv.ParseInt(strArrMerchant[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			merchant, err := s.FindMerchantByID(strArrMerchant[0])
+			if err == nil {
+				merchant.Name = strArrMerchant[1]
+				merchant.Balance = types.Money(balance)
+			} else {
+				s.merchants = append(s.merchants, &types.Merchant{
+					ID:      strArrMerchant[0],
+					Name:    strArrMerchant[1],
+					Balance: types.Money(balance),
+				})
+			}
 		}
+	}
+
+	_, err12 := os.Stat(dir + "/merchant_entries.dump")
+
+	if err12 == nil {
+		content, err := os.ReadFile(dir + "/merchant_entries.dump")
 		if err != nil {
 			return err
 		}
-		content = append(content, buf[:read]...)
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrEntry := strings.Split(v, ";")
+
+			amount, err := strconv.ParseInt(strArrEntry[3], 10, 64)
+			if err != nil {
+				return err
+			}
+			created, err := time.Parse(time.RFC3339Nano, strArrEntry[4])
+			if err != nil {
+				return err
+			}
+			data := &types.MerchantEntry{
+				ID:         strArrEntry[0],
+				MerchantID: strArrEntry[1],
+				PaymentID:  strArrEntry[2],
+				Amount:     types.Money(amount),
+				Time:       created,
+				BatchID:    strArrEntry[5],
+			}
+			flag := true
+			for i, v := range s.merchantEntries {
+				if v.ID == data.ID {
+					s.merchantEntries[i] = data
+					flag = false
+				}
+			}
+			if flag {
+				s.merchantEntries = append(s.merchantEntries, data)
+			}
+		}
 	}
-	accounts := strings.Split(string(content), "|")
-	accounts = accounts[:len(accounts)-1]
 
-	for _, acc := range accounts {
-		splits := strings.Split(acc, ";")
+	_, err15 := os.Stat(dir + "/rejections.dump")
 
-		id, err := strconv.Atoi(splits[0])
+	if err15 == nil {
+		content, err := os.ReadFile(dir + "/rejections.dump")
 		if err != nil {
 			return err
 		}
 
-		balance, err := strconv.Atoi(splits[2])
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrRejection := strings.Split(v, ";")
+
+			aid, err := strconv.ParseInt(strArrRejection[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(strArrRejection[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			created, err := parseDumpTime(strArrRejection, 3)
+			if err != nil {
+				return err
+			}
+			data := &types.Rejection{
+				PaymentID: strArrRejection[0],
+				AccountID: aid,
+				Amount:    types.Money(amount),
+				Time:      created,
+			}
+			flag := true
+			for i, v := range s.rejections {
+				if v.PaymentID == data.PaymentID {
+					s.rejections[i] = data
+					flag = false
+				}
+			}
+			if flag {
+				s.rejections = append(s.rejections, data)
+			}
+		}
+	}
+
+	_, err13 := os.Stat(dir + "/risk_events.dump")
+
+	if err13 == nil {
+		content, err := os.ReadFile(dir + "/risk_events.dump")
 		if err != nil {
 			return err
 		}
 
-		account := &types.Account{
-			ID:      int64(id),
-			Phone:   types.Phone(splits[1]),
-			Balance: types.Money(balance),
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
 		}
+		for _, v := range strArray {
+			strArrEvent := strings.SplitN(v, ";", 8)
 
-		s.accounts = append(s.accounts, account)
-	}
-	return nil
-}
-
-func (s *Service) Export(dir string) error {
-	if len(s.accounts) > 0 {
-		file, err := os.OpenFile(dir+"/accounts.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
-		defer func() {
-			if cerr := file.Close(); cerr != nil {
-				if err != nil {
-					err = cerr
-					log.Print(err)
+			aid, err := strconv.ParseInt(strArrEvent[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(strArrEvent[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			created, err := time.Parse(time.RFC3339Nano, strArrEvent[4])
+			if err != nil {
+				return err
+			}
+			var reasons []string
+			if strArrEvent[7] != "" {
+				reasons = strings.Split(strArrEvent[7], "|")
+			}
+			data := &types.RiskEvent{
+				ID:        strArrEvent[0],
+				AccountID: aid,
+				Amount:    types.Money(amount),
+				Category:  types.PaymentCategory(strArrEvent[3]),
+				Time:      created,
+				Decision:  types.RiskDecision(strArrEvent[5]),
+				PaymentID: strArrEvent[6],
+				Reasons:   reasons,
+			}
+			flag := true
+			for i, v := range s.riskEvents {
+				if v.ID == data.ID {
+					s.riskEvents[i] = data
+					flag = false
 				}
 			}
-		}()
+			if flag {
+				s.riskEvents = append(s.riskEvents, data)
+			}
+		}
+	}
 
-		str := ""
+	_, err14 := os.Stat(dir + "/reviews.dump")
 
-		for _, v := range s.accounts {
-			str += fmt.Sprint(v.ID) + ";" + string(v.Phone) + ";" + fmt.Sprint(v.Balance) + "\n"
+	if err14 == nil {
+		content, err := os.ReadFile(dir + "/reviews.dump")
+		if err != nil {
+			return err
 		}
-		file.WriteString(str)
-	}
-	if len(s.payments) > 0 {
-		file, err := os.OpenFile(dir+"/payments.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
-		defer func() {
-			if cerr := file.Close(); cerr != nil {
-				if err != nil {
-					err = cerr
-					log.Print(err)
-				}
-			}
-		}()
 
-		str := ""
-		for _, v := range s.payments {
-			str += fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + fmt.Sprint(v.Category) + ";" + fmt.Sprint(v.Status) + "\n"
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
 		}
-		file.WriteString(str)
-	}
-	if len(s.favorites) > 0 {
-		file, err := os.OpenFile(dir+"/favorites.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
-		defer func() {
-			if cerr := file.Close(); cerr != nil {
-				if err != nil {
-					err = cerr
-					log.Print(err)
+		for _, v := range strArray {
+			strArrReview := strings.SplitN(v, ";", 5)
+
+			created, err := time.Parse(time.RFC3339Nano, strArrReview[3])
+			if err != nil {
+				return err
+			}
+			data := &types.Review{
+				PaymentID: strArrReview[0],
+				Reviewer:  strArrReview[1],
+				Decision:  types.ReviewDecision(strArrReview[2]),
+				Time:      created,
+				Reason:    strArrReview[4],
+			}
+			// у платежа может быть только одно решение
+			flag := true
+			for i, v := range s.reviews {
+				if v.PaymentID == data.PaymentID {
+					s.reviews[i] = data
+					flag = false
 				}
 			}
-		}()
-
-		str := ""
-
-		for _, v := range s.favorites {
-			str += fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + fmt.Sprint(v.Category) + "\n"
+			if flag {
+				s.reviews = append(s.reviews, data)
+			}
 		}
-		file.WriteString(str)
 	}
-	return nil
-}
 
-func (s *Service) Import(dir string) error {
+	_, err16 := os.Stat(dir + "/phone_changes.dump")
 
-	_, err := os.Stat(dir + "/accounts.dump")
-
-	if err == nil {
-		content, err := os.ReadFile(dir + "/accounts.dump")
+	if err16 == nil {
+		content, err := os.ReadFile(dir + "/phone_changes.dump")
 		if err != nil {
 			return err
 		}
@@ -328,40 +1788,39 @@ func (s *Service) Import(dir string) error {
 			strArray = strArray[:len(strArray)-1]
 		}
 		for _, v := range strArray {
-			strArrAcount := strings.Split(v, ";")
-			fmt.Println(strArrAcount)
+			strArrChange := strings.Split(v, ";")
 
-			id, err := strconv.ParseInt(strArrAcount[0], 10, 64)
+			aid, err := strconv.ParseInt(strArrChange[0], 10, 64)
 			if err != nil {
 				return err
 			}
-			balance, err := strconv.ParseInt(strArrAcount[2], 10, 64)
+			created, err := time.Parse(time.RFC3339Nano, strArrChange[3])
 			if err != nil {
 				return err
 			}
+			data := &types.PhoneChange{
+				AccountID: aid,
+				OldPhone:  types.Phone(strArrChange[1]),
+				NewPhone:  types.Phone(strArrChange[2]),
+				Time:      created,
+			}
+			// повторный импорт того же дампа не должен дублировать историю
 			flag := true
-			for _, v := range s.accounts {
-				if v.ID == id {
-					v.Phone = types.Phone(strArrAcount[1])
-					v.Balance = types.Money(balance)
+			for _, v := range s.phoneChanges {
+				if v.AccountID == data.AccountID && v.NewPhone == data.NewPhone && v.Time.Equal(data.Time) {
 					flag = false
 				}
 			}
 			if flag {
-				account := &types.Account{
-					ID:      id,
-					Phone:   types.Phone(strArrAcount[1]),
-					Balance: types.Money(balance),
-				}
-				s.accounts = append(s.accounts, account)
+				s.phoneChanges = append(s.phoneChanges, data)
 			}
 		}
 	}
 
-	_, err1 := os.Stat(dir + "/payments.dump")
+	_, err17 := os.Stat(dir + "/schedules.dump")
 
-	if err1 == nil {
-		content, err := os.ReadFile(dir + "/payments.dump")
+	if err17 == nil {
+		content, err := os.ReadFile(dir + "/schedules.dump")
 		if err != nil {
 			return err
 		}
@@ -371,48 +1830,95 @@ func (s *Service) Import(dir string) error {
 			strArray = strArray[:len(strArray)-1]
 		}
 		for _, v := range strArray {
-			strArrAcount := strings.Split(v, ";")
-			fmt.Println(strArrAcount)
+			strArrSchedule := strings.Split(v, ";")
 
-			id := strArrAcount[0]
+			nextRun, err := time.Parse(time.RFC3339Nano, strArrSchedule[4])
 			if err != nil {
 				return err
 			}
-			aid, err := strconv.ParseInt(strArrAcount[1], 10, 64)
+			retryAt, err := parseDumpTime(strArrSchedule, 5)
 			if err != nil {
 				return err
 			}
-			amount, err := strconv.ParseInt(strArrAcount[2], 10, 64)
+			attempts, err := strconv.Atoi(strArrSchedule[6])
+			if err != nil {
+				return err
+			}
+			active, err := strconv.ParseBool(strArrSchedule[7])
+			if err != nil {
+				return err
+			}
+			anchor, err := parseDumpTime(strArrSchedule, 8)
 			if err != nil {
 				return err
 			}
+			data := &types.Schedule{
+				ID:         strArrSchedule[0],
+				FavoriteID: strArrSchedule[1],
+				Interval:   types.ScheduleInterval(strArrSchedule[2]),
+				Cron:       strArrSchedule[3],
+				NextRun:    nextRun,
+				RetryAt:    retryAt,
+				Attempts:   attempts,
+				Active:     active,
+				Anchor:     anchor,
+			}
 			flag := true
-			for _, v := range s.payments {
-				if v.ID == id {
-					v.AccountID = aid
-					v.Amount = types.Money(amount)
-					v.Category = types.PaymentCategory(strArrAcount[3])
-					v.Status = types.PaymentStatus(strArrAcount[4])
+			for i, v := range s.schedules {
+				if v.ID == data.ID {
+					s.schedules[i] = data
 					flag = false
 				}
 			}
 			if flag {
-				data := &types.Payment{
-					ID:        id,
-					AccountID: aid,
-					Amount:    types.Money(amount),
-					Category:  types.PaymentCategory(strArrAcount[3]),
-					Status:    types.PaymentStatus(strArrAcount[4]),
+				s.schedules = append(s.schedules, data)
+			}
+		}
+	}
+
+	_, err18 := os.Stat(dir + "/schedule_runs.dump")
+
+	if err18 == nil {
+		content, err := os.ReadFile(dir + "/schedule_runs.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+		for _, v := range strArray {
+			strArrRun := strings.Split(v, ";")
+
+			ran, err := time.Parse(time.RFC3339Nano, strArrRun[2])
+			if err != nil {
+				return err
+			}
+			data := &types.ScheduleRun{
+				ScheduleID: strArrRun[0],
+				PaymentID:  strArrRun[1],
+				Time:       ran,
+				Status:     types.ScheduleRunStatus(strArrRun[3]),
+				Error:      strArrRun[4],
+			}
+			// у запуска нет своего ID: одно расписание в один момент запускается один раз
+			flag := true
+			for _, v := range s.scheduleRuns {
+				if v.ScheduleID == data.ScheduleID && v.Time.Equal(data.Time) {
+					flag = false
 				}
-				s.payments = append(s.payments, data)
+			}
+			if flag {
+				s.scheduleRuns = append(s.scheduleRuns, data)
 			}
 		}
 	}
 
-	_, err2 := os.Stat(dir + "/favorites.dump")
+	_, err19 := os.Stat(dir + "/deleted_favorites.dump")
 
-	if err2 == nil {
-		content, err := os.ReadFile(dir + "/favorites.dump")
+	if err19 == nil {
+		content, err := os.ReadFile(dir + "/deleted_favorites.dump")
 		if err != nil {
 			return err
 		}
@@ -422,42 +1928,48 @@ func (s *Service) Import(dir string) error {
 			strArray = strArray[:len(strArray)-1]
 		}
 		for _, v := range strArray {
-			strArrAcount := strings.Split(v, ";")
-			fmt.Println(strArrAcount)
+			strArrFavorite := strings.Split(v, ";")
 
-			id := strArrAcount[0]
+			aid, err := strconv.ParseInt(strArrFavorite[1], 10, 64)
 			if err != nil {
 				return err
 			}
-			aid, err := strconv.ParseInt(strArrAcount[1], 10, 64)
+			amount, err := strconv.ParseInt(strArrFavorite[2], 10, 64)
 			if err != nil {
 				return err
 			}
-			amount, err := strconv.ParseInt(strArrAcount[2], 10, 64)
+			seq, err := parseDumpSeq(strArrFavorite, 5)
+			if err != nil {
+				return err
+			}
+			changed, err := parseDumpSeq(strArrFavorite, 6)
 			if err != nil {
 				return err
 			}
+			data := &types.Favorite{
+				ID:        strArrFavorite[0],
+				Seq:       seq,
+				Changed:   changed,
+				AccountID: aid,
+				Amount:    types.Money(amount),
+				Category:  types.PaymentCategory(strArrFavorite[3]),
+				Name:      strArrFavorite[4],
+			}
 			flag := true
-			for _, v := range s.favorites {
-				if v.ID == id {
-					v.AccountID = aid
-					v.Amount = types.Money(amount)
-					v.Category = types.PaymentCategory(strArrAcount[3])
+			for _, v := range s.deleted {
+				if v.ID == data.ID {
 					flag = false
 				}
 			}
 			if flag {
-				data := &types.Favorite{
-					ID:        id,
-					AccountID: aid,
-					Amount:    types.Money(amount),
-					Category:  types.PaymentCategory(strArrAcount[3]),
-				}
-				s.favorites = append(s.favorites, data)
+				s.deleted = append(s.deleted, data)
 			}
 		}
 	}
 
+	s.resequence()
+	s.indexIDs()
+	s.publish(Imported{Dir: dir})
 	return nil
 }
 
@@ -474,49 +1986,33 @@ func (s *Service) ExportAccountHistory(accountID int64) ([]types.Payment, error)
 		if acc.ID == pay.AccountID {
 			data := types.Payment{
 				ID:        pay.ID,
+				Seq:       pay.Seq,
+				Changed:   pay.Changed,
 				AccountID: pay.AccountID,
 				Amount:    pay.Amount,
 				Category:  pay.Category,
 				Status:    pay.Status,
+				Time:      pay.Time,
 			}
 			payments = append(payments, data)
-		}
-	}
-
-	return payments, nil
-}
-
-func (s *Service) HistoryToFiles(payments []types.Payment, dir string, records int) error {
-	str := ""
 
-	if len(payments) > 0 && len(payments) <= records {
-		file, _ := os.OpenFile(dir+"/payments.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
-		defer file.Close()
-
-		for _, v := range payments {
-			str += fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + fmt.Sprint(v.Category) + ";" + fmt.Sprint(v.Status) + "\n"
-		}
-		file.WriteString(str)
-	} else {
-		k := 0 // limit on record
-		t := 1 // count for files
-		var file *os.File
-		for _, v := range payments {
-			if k == 0 {
-				file, _ = os.OpenFile(dir+"/payments"+fmt.Sprint(t)+".dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
-			}
-			k++
-			str = fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + fmt.Sprint(v.Category) + ";" + fmt.Sprint(v.Status) + "\n"
-			_, _ = file.WriteString(str)
-			if k == records { // если лимит был дастигнут, то обнулить "записи"
-				str = ""
-				t++
-				k = 0
-				file.Close()
+			// каждый возврат идёт в истории отдельной записью сразу после платежа
+			for _, refund := range s.refunds {
+				if refund.PaymentID == pay.ID {
+					payments = append(payments, types.Payment{
+						ID:        refund.ID,
+						AccountID: refund.AccountID,
+						Amount:    refund.Amount,
+						Category:  pay.Category,
+						Status:    types.PaymentStatusRefund,
+						Time:      refund.Time,
+					})
+				}
 			}
 		}
 	}
-	return nil
+
+	return payments, nil
 }
 
 func (s *Service) SumPayments(goroutines int) types.Money {
@@ -609,10 +2105,10 @@ func (s *Service) SumPayments(goroutines int) types.Money {
 // 		sum += val
 // 		mu.Unlock()
 
-// 	}()
-// 	wg.Wait()
-// 	return payments, nil
-// }
+//		}()
+//		wg.Wait()
+//		return payments, nil
+//	}
 func (s *Service) FilterPayments(accountID int64, goroutines int) ([]types.Payment, error) {
 
 	_, err := s.FindAccountByID(accountID)
@@ -681,7 +2177,7 @@ func (s *Service) FilterPayments(accountID int64, goroutines int) ([]types.Payme
 	return ps, nil
 }
 
-//FilterPaymentsByFn ...
+// FilterPaymentsByFn ...
 func (s *Service) FilterPaymentsByFn(filter func(payment types.Payment) bool, goroutines int) ([]types.Payment, error) {
 
 	wg := sync.WaitGroup{}
@@ -810,7 +2306,6 @@ func (s *Service) SumPaymentsWithProgress() <-chan types.Progress {
 	return ch
 }
 
-
 func merge(channels []<-chan types.Progress) <-chan types.Progress {
 	wg := sync.WaitGroup{}
 	wg.Add(len(channels))
@@ -829,3 +2324,36 @@ func merge(channels []<-chan types.Progress) <-chan types.Progress {
 	}()
 	return merged
 }
+
+// formatDumpTime записывает время в дамп; нулевое время (записи из старых дампов) - пустая строка
+func formatDumpTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseDumpTime читает необязательное поле времени i, которого нет в дампах старого формата
+func parseDumpTime(fields []string, i int) (time.Time, error) {
+	if len(fields) <= i || fields[i] == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, fields[i])
+}
+
+// parseDumpMoney читает необязательную сумму i; в дампах старого формата её нет, это 0
+func parseDumpMoney(fields []string, i int) (types.Money, error) {
+	if len(fields) <= i || fields[i] == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseInt(fields[i], 10, 64)
+	return types.Money(amount), err
+}
+
+// parseDumpSeq читает необязательный порядковый номер i; 0 - номера нет, его назначит resequence
+func parseDumpSeq(fields []string, i int) (int64, error) {
+	if len(fields) <= i || fields[i] == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(fields[i], 10, 64)
+}
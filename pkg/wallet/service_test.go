@@ -3,6 +3,8 @@ package wallet
 import (
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -76,9 +78,9 @@ func (s *testService) addAccountWithBalance(phone types.Phone, balance types.Mon
 
 func TestService_RegisterAccount_Fail(t *testing.T) {
 	svc := Service{}
-	svc.RegisterAccount("+9920000001")
+	svc.RegisterAccount("+992000000001")
 
-	_, err := svc.RegisterAccount("+9920000001")
+	_, err := svc.RegisterAccount("+992000000001")
 	if err != ErrPhoneRegistered {
 		t.Errorf("%v", err)
 	}
@@ -86,7 +88,7 @@ func TestService_RegisterAccount_Fail(t *testing.T) {
 
 func TestService_RegisterAccount_success(t *testing.T) {
 	svc := Service{}
-	svc.RegisterAccount("+9920000001")
+	svc.RegisterAccount("+992000000001")
 
 	account, err := svc.FindAccountByID(svc.nextAccountID)
 	if err != nil {
@@ -96,7 +98,7 @@ func TestService_RegisterAccount_success(t *testing.T) {
 
 func TestService_Deposit_success(t *testing.T) {
 	svc := Service{}
-	newAcc, err := svc.RegisterAccount("+9920000001")
+	newAcc, err := svc.RegisterAccount("+992000000001")
 	if err != nil {
 		t.Errorf("%v", err)
 	}
@@ -110,7 +112,7 @@ func TestService_Deposit_success(t *testing.T) {
 
 func TestService_Deposit_fail_amount(t *testing.T) {
 	svc := Service{}
-	newAcc, err := svc.RegisterAccount("+9920000001")
+	newAcc, err := svc.RegisterAccount("+992000000001")
 	if err != nil {
 		t.Errorf("%v", err)
 	}
@@ -123,7 +125,7 @@ func TestService_Deposit_fail_amount(t *testing.T) {
 }
 func TestService_Deposit_fail_account(t *testing.T) {
 	svc := Service{}
-	_, err := svc.RegisterAccount("+9920000001")
+	_, err := svc.RegisterAccount("+992000000001")
 	if err != nil {
 		t.Errorf("%v", err)
 	}
@@ -137,7 +139,7 @@ func TestService_Deposit_fail_account(t *testing.T) {
 
 func TestService_Pay_success(t *testing.T) {
 	svc := Service{}
-	account, err := svc.RegisterAccount("+9920000001")
+	account, err := svc.RegisterAccount("+992000000001")
 	if err != nil {
 		t.Errorf("%v", err)
 	}
@@ -161,7 +163,7 @@ func TestService_Pay_success(t *testing.T) {
 
 func TestService_Pay_Fail_Amount(t *testing.T) {
 	svc := Service{}
-	account, err := svc.RegisterAccount("+9920000001")
+	account, err := svc.RegisterAccount("+992000000001")
 	if err != nil {
 		t.Errorf("%v", err)
 	}
@@ -180,7 +182,7 @@ func TestService_Pay_Fail_Amount(t *testing.T) {
 
 func TestService_Pay_Fail_Account(t *testing.T) {
 	svc := Service{}
-	account, err := svc.RegisterAccount("+9920000001")
+	account, err := svc.RegisterAccount("+992000000001")
 	if err != nil {
 		t.Errorf("%v", err)
 	}
@@ -199,7 +201,7 @@ func TestService_Pay_Fail_Account(t *testing.T) {
 
 func TestService_Pay_Fail_Balance(t *testing.T) {
 	svc := Service{}
-	account, err := svc.RegisterAccount("+9920000001")
+	account, err := svc.RegisterAccount("+992000000001")
 	if err != nil {
 		t.Errorf("%v", err)
 	}
@@ -228,6 +230,7 @@ func TestService_FindAccountById_success(t *testing.T) {
 		ID:      1,
 		Phone:   "+992926421505",
 		Balance: 0,
+		Status:  types.AccountStatusActive,
 	}
 
 	if !reflect.DeepEqual(&myResult, result) {
@@ -619,7 +622,7 @@ func TestService_ExportHistory_success_user(t *testing.T) {
 		t.Errorf("method ExportAccountHistory returned not nil error, err => %v", err)
 	}
 
-	err = svc.HistoryToFiles(payments, "../../data", 2)
+	err = svc.HistoryToFiles(payments, t.TempDir(), 2)
 	if err != nil {
 		t.Errorf("method HistoryToFiles returned not nil error, err => %v", err)
 	}
@@ -651,7 +654,7 @@ func TestService_Export_success(t *testing.T) {
 		t.Errorf("method Pay returned not nil error, err => %v", err)
 	}
 
-	err = svc.Export("date")
+	err = svc.Export(t.TempDir())
 	if err != nil {
 		t.Errorf("method Export returned not nil error, err => %v", err)
 	}
@@ -683,7 +686,7 @@ func TestService_ExportHistory_success_one_file(t *testing.T) {
 		t.Errorf("method ExportAccountHistory returned not nil error, err => %v", err)
 	}
 
-	err = svc.HistoryToFiles(payments, "../../data", 1)
+	err = svc.HistoryToFiles(payments, t.TempDir(), 1)
 	if err != nil {
 		t.Errorf("method HistoryToFiles returned not nil error, err => %v", err)
 	}
@@ -718,4 +721,481 @@ func BenchmarkSumPaymentsWithProgress_user(b *testing.B) {
 
 	log.Println("=======>>>>>",s) 
 
-}
\ No newline at end of file
+}
+func TestService_Refund_success(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992926421505", 10_000_00)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 1000_00, "shop")
+	if err != nil {
+		t.Errorf("Refund(): can't create payment, error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 300_00)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+	_, err = s.Refund(payment.ID, 200_00)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	if account.Balance != 9_500_00 {
+		t.Errorf("Refund(): wrong balance, expected: %v, actual: %v", types.Money(9_500_00), account.Balance)
+		return
+	}
+
+	refunded, err := s.RefundedAmount(payment.ID)
+	if err != nil {
+		t.Errorf("RefundedAmount(): error = %v", err)
+		return
+	}
+	if refunded != 500_00 {
+		t.Errorf("RefundedAmount(): expected: %v, actual: %v", types.Money(500_00), refunded)
+		return
+	}
+
+	history, err := s.ExportAccountHistory(account.ID)
+	if err != nil {
+		t.Errorf("ExportAccountHistory(): error = %v", err)
+		return
+	}
+	if len(history) != 3 || history[1].Status != types.PaymentStatusRefund || history[2].Amount != 200_00 {
+		t.Errorf("ExportAccountHistory(): refunds must follow payment, actual: %v", history)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	if account.Balance != 10_000_00 {
+		t.Errorf("Reject(): must return only not refunded amount, actual balance: %v", account.Balance)
+	}
+}
+
+func TestService_Refund_fail_exceeds(t *testing.T) {
+	s := newTestService()
+	account, err := s.addAccountWithBalance("+992926421505", 10_000_00)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 1000_00, "shop")
+	if err != nil {
+		t.Errorf("Refund(): can't create payment, error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 800_00)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 300_00)
+	if err != ErrRefundExceedsPayment {
+		t.Errorf("Refund(): must return ErrRefundExceedsPayment, returned = %v", err)
+		return
+	}
+}
+
+func TestService_Refund_fail_rejected(t *testing.T) {
+	s := newTestService()
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+	payment := payments[0]
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 1)
+	if err != ErrPaymentRejected {
+		t.Errorf("Refund(): must return ErrPaymentRejected, returned = %v", err)
+		return
+	}
+}
+
+func TestService_FavoritePayment_fail_name_taken(t *testing.T) {
+	s := newTestService()
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+	payment := payments[0]
+
+	_, err = s.FavoritePayment(payment.ID, "score AlifAcademy")
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+
+	_, err = s.FavoritePayment(payment.ID, "score AlifAcademy")
+	if err != ErrFavoriteNameTaken {
+		t.Errorf("FavoritePayment(): must return ErrFavoriteNameTaken, returned = %v", err)
+		return
+	}
+}
+
+func TestService_UpdateFavorite_success(t *testing.T) {
+	s := newTestService()
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+	payment := payments[0]
+
+	first, err := s.FavoritePayment(payment.ID, "internet")
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+	_, err = s.FavoritePayment(payment.ID, "phone")
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+
+	_, err = s.UpdateFavorite(first.ID, "phone", 500)
+	if err != ErrFavoriteNameTaken {
+		t.Errorf("UpdateFavorite(): must return ErrFavoriteNameTaken, returned = %v", err)
+		return
+	}
+	_, err = s.UpdateFavorite(first.ID, "home internet", 0)
+	if err != ErrAmountMustBePositive {
+		t.Errorf("UpdateFavorite(): must return ErrAmountMustBePositive, returned = %v", err)
+		return
+	}
+
+	favorite, err := s.UpdateFavorite(first.ID, "home internet", 500)
+	if err != nil {
+		t.Errorf("UpdateFavorite(): error = %v", err)
+		return
+	}
+	if favorite.Name != "home internet" || favorite.Amount != 500 {
+		t.Errorf("UpdateFavorite(): wrong favorite = %v", favorite)
+		return
+	}
+
+	favorites, err := s.FavoritesByAccount(account.ID)
+	if err != nil {
+		t.Errorf("FavoritesByAccount(): error = %v", err)
+		return
+	}
+	if len(favorites) != 2 {
+		t.Errorf("FavoritesByAccount(): expected 2 favorites, actual: %v", favorites)
+	}
+}
+
+func TestService_DeleteFavorite_success(t *testing.T) {
+	s := newTestService()
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+
+	favorite, err := s.FavoritePayment(payments[0].ID, "internet")
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+
+	err = s.DeleteFavorite(favorite.ID)
+	if err != nil {
+		t.Errorf("DeleteFavorite(): error = %v", err)
+		return
+	}
+
+	_, err = s.FindFavoriteByID(favorite.ID)
+	if err != ErrFavoriteNotFound {
+		t.Errorf("FindFavoriteByID(): must return ErrFavoriteNotFound, returned = %v", err)
+		return
+	}
+
+	favorites, err := s.FavoritesByAccount(account.ID)
+	if err != nil || len(favorites) != 0 {
+		t.Errorf("FavoritesByAccount(): expected no favorites, actual: %v, error = %v", favorites, err)
+		return
+	}
+
+	err = s.DeleteFavorite(favorite.ID)
+	if err != ErrFavoriteNotFound {
+		t.Errorf("DeleteFavorite(): must return ErrFavoriteNotFound, returned = %v", err)
+	}
+}
+
+func TestService_Import_favorite_name(t *testing.T) {
+	s := newTestService()
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+
+	favorite, err := s.FavoritePayment(payments[0].ID, "internet")
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+
+	svc := &Service{}
+	err = svc.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	got, err := svc.FindFavoriteByID(favorite.ID)
+	if err != nil {
+		t.Errorf("FindFavoriteByID(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(favorite, got) {
+		t.Errorf("Import(): expected: %v, actual: %v", favorite, got)
+	}
+}
+
+func TestService_Import_duplicatePhone(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "accounts.dump"), []byte("1;+992926421509;100\n2;992926421509;200\n"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Service{}
+	err = s.Import(dir)
+	if err != ErrPhoneRegistered {
+		t.Errorf("Import(): must return ErrPhoneRegistered, returned = %v", err)
+	}
+}
+
+func TestService_Export_deletedFavorite(t *testing.T) {
+	s := newTestService()
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+	favorite, err := s.FavoritePayment(payments[0].ID, "internet")
+	if err != nil {
+		t.Errorf("%v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	err = s.DeleteFavorite(favorite.ID)
+	if err != nil {
+		t.Errorf("DeleteFavorite(): error = %v", err)
+		return
+	}
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+
+	svc := &Service{}
+	err = svc.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	if _, err := svc.FindFavoriteByID(favorite.ID); err != ErrFavoriteNotFound {
+		t.Errorf("Import(): deleted favorite came back, error = %v", err)
+		return
+	}
+
+	file := filepath.Join(dir, "file")
+	err = os.WriteFile(file, nil, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Export(file)
+	if err == nil {
+		t.Errorf("Export(): expected error when dir is a file")
+	}
+}
+
+func TestService_FreezeAccount_success(t *testing.T) {
+	s := newTestService()
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+
+	err = s.FreezeAccount(account.ID)
+	if err != nil {
+		t.Errorf("FreezeAccount(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 1000, "auto")
+	if err != ErrAccountFrozen {
+		t.Errorf("Pay(): must return ErrAccountFrozen, returned = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 1000)
+	if err != ErrAccountFrozen {
+		t.Errorf("Deposit(): must return ErrAccountFrozen, returned = %v", err)
+		return
+	}
+	_, err = s.Repeat(payments[0].ID)
+	if err != ErrAccountFrozen {
+		t.Errorf("Repeat(): must return ErrAccountFrozen, returned = %v", err)
+		return
+	}
+
+	err = s.UnfreezeAccount(account.ID)
+	if err != nil {
+		t.Errorf("UnfreezeAccount(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 1000, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+	}
+}
+
+func TestService_CloseAccount_fail_balance(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+
+	err = s.CloseAccount(account.ID)
+	if err != ErrAccountHasBalance {
+		t.Errorf("CloseAccount(): must return ErrAccountHasBalance, returned = %v", err)
+	}
+}
+
+func TestService_PayoutAndClose_success(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Errorf("error = %v", err)
+		return
+	}
+
+	err = s.FreezeAccount(account.ID)
+	if err != nil {
+		t.Errorf("FreezeAccount(): error = %v", err)
+		return
+	}
+
+	payment, err := s.PayoutAndClose(account.ID, "payout")
+	if err != nil {
+		t.Errorf("PayoutAndClose(): error = %v", err)
+		return
+	}
+	if payment.Amount != 990_000 || account.Balance != 0 || account.Status != types.AccountStatusClosed {
+		t.Errorf("PayoutAndClose(): wrong result, payment: %v, account: %v", payment, account)
+		return
+	}
+
+	err = s.Deposit(account.ID, 1000)
+	if err != ErrAccountClosed {
+		t.Errorf("Deposit(): must return ErrAccountClosed, returned = %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	svc := &Service{}
+	err = svc.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	imported, err := svc.FindAccountByID(account.ID)
+	if err != nil || imported.Status != types.AccountStatusClosed {
+		t.Errorf("Import(): status must be persisted, account: %v, error = %v", imported, err)
+	}
+}
+
+func TestService_RegisterAccount_normalize(t *testing.T) {
+	svc := Service{}
+	account, err := svc.RegisterAccount("992926421509")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	if account.Phone != "+992926421509" {
+		t.Errorf("RegisterAccount(): phone must be normalized, actual: %v", account.Phone)
+		return
+	}
+
+	for _, phone := range []types.Phone{"+992926421509", "92 642 15 09", "00 992 (92) 642-15-09"} {
+		_, err = svc.RegisterAccount(phone)
+		if err != ErrPhoneRegistered {
+			t.Errorf("RegisterAccount(%v): must return ErrPhoneRegistered, returned = %v", phone, err)
+		}
+	}
+
+	for _, phone := range []types.Phone{"", "+99292642150", "+1 202 555 0100", "92642150a"} {
+		_, err = svc.RegisterAccount(phone)
+		if err != ErrInvalidPhone {
+			t.Errorf("RegisterAccount(%v): must return ErrInvalidPhone, returned = %v", phone, err)
+		}
+	}
+}
+
+func TestService_SetPhoneRules_success(t *testing.T) {
+	svc := Service{}
+	svc.SetPhoneRules(PhoneRules{
+		Countries:      []CountryRule{{Country: "US", Code: "1", NationalLength: 10}},
+		DefaultCountry: "US",
+	})
+
+	account, err := svc.RegisterAccount("(202) 555-0100")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	if account.Phone != "+12025550100" {
+		t.Errorf("RegisterAccount(): expected: +12025550100, actual: %v", account.Phone)
+		return
+	}
+
+	_, err = svc.RegisterAccount("+992926421509")
+	if err != ErrInvalidPhone {
+		t.Errorf("RegisterAccount(): must return ErrInvalidPhone, returned = %v", err)
+	}
+}
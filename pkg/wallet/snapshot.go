@@ -0,0 +1,348 @@
+package wallet
+
+import (
+	"errors"
+	"os"
+	"strconv"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrMergeConflict = errors.New("snapshots have conflicting records")
+var ErrInvalidMergePolicy = errors.New("invalid merge policy")
+
+// ChangeKind - вид изменения записи между двумя снимками
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "ADDED"
+	ChangeRemoved ChangeKind = "REMOVED"
+	ChangeChanged ChangeKind = "CHANGED"
+)
+
+// AccountChange - изменение счёта; Old пуст для добавленных, New - для удалённых
+type AccountChange struct {
+	Kind ChangeKind     `json:"kind"`
+	ID   int64          `json:"id"`
+	Old  *types.Account `json:"old,omitempty"`
+	New  *types.Account `json:"new,omitempty"`
+}
+
+// PaymentChange - изменение платежа
+type PaymentChange struct {
+	Kind ChangeKind     `json:"kind"`
+	ID   string         `json:"id"`
+	Old  *types.Payment `json:"old,omitempty"`
+	New  *types.Payment `json:"new,omitempty"`
+}
+
+// FavoriteChange - изменение избранного
+type FavoriteChange struct {
+	Kind ChangeKind      `json:"kind"`
+	ID   string          `json:"id"`
+	Old  *types.Favorite `json:"old,omitempty"`
+	New  *types.Favorite `json:"new,omitempty"`
+}
+
+// SnapshotDiff - различия счетов, платежей и избранного между двумя состояниями кошелька
+type SnapshotDiff struct {
+	Accounts  []AccountChange  `json:"accounts"`
+	Payments  []PaymentChange  `json:"payments"`
+	Favorites []FavoriteChange `json:"favorites"`
+}
+
+// Empty сообщает, что состояния не различаются
+func (d *SnapshotDiff) Empty() bool {
+	return len(d.Accounts) == 0 && len(d.Payments) == 0 && len(d.Favorites) == 0
+}
+
+// MergePolicy - как Merge разрешает конфликт: запись есть в обоих состояниях, но различается
+type MergePolicy string
+
+const (
+	// MergeOurs оставляет текущую запись
+	MergeOurs MergePolicy = "ours"
+	// MergeTheirs заменяет текущую запись записью из выгрузки
+	MergeTheirs MergePolicy = "theirs"
+	// MergeFail ничего не меняет и возвращает ErrMergeConflict
+	MergeFail MergePolicy = "fail"
+)
+
+// MergeConflict - конфликтующая запись. Kind - "account", "payment" или "favorite".
+// Счёт с другим ID и тем же телефоном тоже конфликт, но его нельзя принять по MergeTheirs,
+// как и платёж или избранное чужого счёта.
+type MergeConflict struct {
+	Kind   string      `json:"kind"`
+	ID     string      `json:"id"`
+	Ours   interface{} `json:"ours"`
+	Theirs interface{} `json:"theirs"`
+}
+
+// DiffSnapshots сравнивает две выгрузки Export: что изменилось в newDir относительно oldDir
+func DiffSnapshots(oldDir string, newDir string) (*SnapshotDiff, error) {
+	older, err := loadSnapshot(oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := loadSnapshot(newDir)
+	if err != nil {
+		return nil, err
+	}
+	return older.Diff(newer), nil
+}
+
+// Diff сравнивает текущее состояние с other: что изменилось в other относительно s
+func (s *Service) Diff(other *Service) *SnapshotDiff {
+	diff := &SnapshotDiff{Accounts: []AccountChange{}, Payments: []PaymentChange{}, Favorites: []FavoriteChange{}}
+
+	for _, account := range s.accounts {
+		changed := other.accountByID(account.ID)
+		if changed == nil {
+			diff.Accounts = append(diff.Accounts, AccountChange{Kind: ChangeRemoved, ID: account.ID, Old: account})
+		} else if *changed != *account {
+			diff.Accounts = append(diff.Accounts, AccountChange{Kind: ChangeChanged, ID: account.ID, Old: account, New: changed})
+		}
+	}
+	for _, account := range other.accounts {
+		if s.accountByID(account.ID) == nil {
+			diff.Accounts = append(diff.Accounts, AccountChange{Kind: ChangeAdded, ID: account.ID, New: account})
+		}
+	}
+
+	for _, payment := range s.payments {
+		changed := other.paymentByID(payment.ID)
+		if changed == nil {
+			diff.Payments = append(diff.Payments, PaymentChange{Kind: ChangeRemoved, ID: payment.ID, Old: payment})
+		} else if !samePayment(payment, changed) {
+			diff.Payments = append(diff.Payments, PaymentChange{Kind: ChangeChanged, ID: payment.ID, Old: payment, New: changed})
+		}
+	}
+	for _, payment := range other.payments {
+		if s.paymentByID(payment.ID) == nil {
+			diff.Payments = append(diff.Payments, PaymentChange{Kind: ChangeAdded, ID: payment.ID, New: payment})
+		}
+	}
+
+	for _, favorite := range s.favorites {
+		changed := other.favoriteByID(favorite.ID)
+		if changed == nil {
+			diff.Favorites = append(diff.Favorites, FavoriteChange{Kind: ChangeRemoved, ID: favorite.ID, Old: favorite})
+		} else if !sameFavorite(favorite, changed) {
+			diff.Favorites = append(diff.Favorites, FavoriteChange{Kind: ChangeChanged, ID: favorite.ID, Old: favorite, New: changed})
+		}
+	}
+	for _, favorite := range other.favorites {
+		if s.favoriteByID(favorite.ID) == nil {
+			diff.Favorites = append(diff.Favorites, FavoriteChange{Kind: ChangeAdded, ID: favorite.ID, New: favorite})
+		}
+	}
+	return diff
+}
+
+// Merge добавляет в текущее состояние счета, платежи и избранное из выгрузки dir.
+// Записи, которых нет в выгрузке, остаются; различающиеся записи разрешаются по policy.
+// Платёж или избранное из выгрузки принимается, только если после слияния счёт с его
+// AccountID принадлежит тому же телефону, что и в выгрузке; иначе запись - конфликт без Ours.
+// Счёт из выгрузки с телефоном, занятым другим нашим счётом, не сливается ни при какой policy.
+// Добавленные платежи и избранное получают новые порядковые номера после текущих.
+// Пополнения, возвраты, комиссии, отмены и переводы не сливаются, поэтому после Merge
+// Reconcile может показать расхождения по счетам из выгрузки.
+// Возвращает все найденные конфликты; при MergeFail и конфликтах состояние не меняется.
+func (s *Service) Merge(dir string, policy MergePolicy) (_ []MergeConflict, rerr error) {
+	defer s.audited("Merge", auditArgs("dir", dir, "policy", policy))(&rerr)
+
+	if policy != MergeOurs && policy != MergeTheirs && policy != MergeFail {
+		return nil, ErrInvalidMergePolicy
+	}
+	theirs, err := loadSnapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := s.Diff(theirs)
+	conflicts := []MergeConflict{}
+	unresolved := false
+	// телефоны счетов, какими они станут после слияния
+	owners := make(map[int64]types.Phone)
+	for _, account := range s.accounts {
+		owners[account.ID] = account.Phone
+	}
+	var accounts []*types.Account
+	for _, change := range diff.Accounts {
+		switch change.Kind {
+		case ChangeChanged:
+			conflicts = append(conflicts, MergeConflict{Kind: "account", ID: strconv.FormatInt(change.ID, 10), Ours: *change.Old, Theirs: *change.New})
+			if policy == MergeTheirs {
+				owners[change.ID] = change.New.Phone
+			}
+		case ChangeAdded:
+			if same := s.accountByPhone(change.New.Phone); same != nil {
+				conflicts = append(conflicts, MergeConflict{Kind: "account", ID: strconv.FormatInt(change.ID, 10), Ours: *same, Theirs: *change.New})
+				unresolved = unresolved || policy != MergeOurs
+				continue
+			}
+			accounts = append(accounts, change.New)
+			owners[change.New.ID] = change.New.Phone
+		}
+	}
+	// их телефон не должен остаться за другим нашим счётом, иначе следующий Import не пройдёт
+	if policy == MergeTheirs {
+		for _, change := range diff.Accounts {
+			if change.Kind != ChangeChanged {
+				continue
+			}
+			for id, phone := range owners {
+				if id != change.ID && phone == change.New.Phone {
+					// телефон может занимать и счёт, добавляемый из их выгрузки
+					other := s.accountByID(id)
+					if other == nil {
+						other = theirs.accountByID(id)
+					}
+					conflicts = append(conflicts, MergeConflict{Kind: "account", ID: strconv.FormatInt(change.ID, 10), Ours: *other, Theirs: *change.New})
+					unresolved = true
+					break
+				}
+			}
+		}
+	}
+	// sameOwner сообщает, что запись выгрузки после слияния останется у того же клиента
+	sameOwner := func(accountID int64) bool {
+		owner := theirs.accountByID(accountID)
+		phone, ok := owners[accountID]
+		return owner != nil && ok && owner.Phone == phone
+	}
+
+	var payments []*types.Payment
+	for _, change := range diff.Payments {
+		switch change.Kind {
+		case ChangeChanged:
+			conflicts = append(conflicts, MergeConflict{Kind: "payment", ID: change.ID, Ours: *change.Old, Theirs: *change.New})
+		case ChangeAdded:
+			if !sameOwner(change.New.AccountID) {
+				conflicts = append(conflicts, MergeConflict{Kind: "payment", ID: change.ID, Theirs: *change.New})
+				unresolved = unresolved || policy != MergeOurs
+				continue
+			}
+			payments = append(payments, change.New)
+		}
+	}
+	var favorites []*types.Favorite
+	for _, change := range diff.Favorites {
+		switch change.Kind {
+		case ChangeChanged:
+			conflicts = append(conflicts, MergeConflict{Kind: "favorite", ID: change.ID, Ours: *change.Old, Theirs: *change.New})
+		case ChangeAdded:
+			if !sameOwner(change.New.AccountID) {
+				conflicts = append(conflicts, MergeConflict{Kind: "favorite", ID: change.ID, Theirs: *change.New})
+				unresolved = unresolved || policy != MergeOurs
+				continue
+			}
+			favorites = append(favorites, change.New)
+		}
+	}
+	if unresolved || policy == MergeFail && len(conflicts) > 0 {
+		return conflicts, ErrMergeConflict
+	}
+
+	for _, account := range accounts {
+		s.accounts = append(s.accounts, account)
+		if account.ID > s.nextAccountID {
+			s.nextAccountID = account.ID
+		}
+	}
+	for _, change := range diff.Accounts {
+		if change.Kind == ChangeChanged && policy == MergeTheirs {
+			*change.Old = *change.New
+		}
+	}
+	for _, payment := range payments {
+		payment.Seq = s.nextSeq()
+		payment.Changed = 0
+		s.payments = append(s.payments, payment)
+	}
+	for _, change := range diff.Payments {
+		if change.Kind == ChangeChanged && policy == MergeTheirs && sameOwner(change.New.AccountID) {
+			// номер создания остаётся нашим, а изменение получает новый номер
+			seq := change.Old.Seq
+			*change.Old = *change.New
+			change.Old.Seq = seq
+			change.Old.Changed = s.nextSeq()
+		}
+	}
+	for _, favorite := range favorites {
+		favorite.Seq = s.nextSeq()
+		favorite.Changed = 0
+		s.favorites = append(s.favorites, favorite)
+	}
+	for _, change := range diff.Favorites {
+		if change.Kind == ChangeChanged && policy == MergeTheirs && sameOwner(change.New.AccountID) {
+			seq := change.Old.Seq
+			*change.Old = *change.New
+			change.Old.Seq = seq
+			change.Old.Changed = s.nextSeq()
+		}
+	}
+	s.indexIDs()
+	s.publish(Merged{Dir: dir, Policy: policy})
+	return conflicts, nil
+}
+
+// loadSnapshot читает выгрузку в отдельный сервис; каталог должен существовать
+func loadSnapshot(dir string) (*Service, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	snapshot := &Service{}
+	err := snapshot.Import(dir)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (s *Service) accountByID(id int64) *types.Account {
+	for _, account := range s.accounts {
+		if account.ID == id {
+			return account
+		}
+	}
+	return nil
+}
+
+func (s *Service) accountByPhone(phone types.Phone) *types.Account {
+	for _, account := range s.accounts {
+		if account.Phone == phone {
+			return account
+		}
+	}
+	return nil
+}
+
+func (s *Service) paymentByID(id string) *types.Payment {
+	for _, payment := range s.payments {
+		if payment.ID == id {
+			return payment
+		}
+	}
+	return nil
+}
+
+func (s *Service) favoriteByID(id string) *types.Favorite {
+	for _, favorite := range s.favorites {
+		if favorite.ID == id {
+			return favorite
+		}
+	}
+	return nil
+}
+
+// samePayment и sameFavorite сравнивают записи без порядковых номеров:
+// у одной и той же записи в разных состояниях номер может отличаться
+func samePayment(a *types.Payment, b *types.Payment) bool {
+	return a.AccountID == b.AccountID && a.Amount == b.Amount && a.Category == b.Category &&
+		a.Status == b.Status && a.Time.Equal(b.Time)
+}
+
+func sameFavorite(a *types.Favorite, b *types.Favorite) bool {
+	return a.AccountID == b.AccountID && a.Name == b.Name && a.Amount == b.Amount && a.Category == b.Category
+}
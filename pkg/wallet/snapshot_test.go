@@ -0,0 +1,214 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+// newSnapshotTestDirs выгружает одно состояние, меняет его и выгружает ещё раз
+func newSnapshotTestDirs(t *testing.T) (string, string, *types.Payment) {
+	s := newTestService()
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount(): error = %v", err)
+	}
+	err = s.Deposit(account.ID, 10_000)
+	if err != nil {
+		t.Fatalf("Deposit(): error = %v", err)
+	}
+	payment, err := s.Pay(account.ID, 1_000, "food")
+	if err != nil {
+		t.Fatalf("Pay(): error = %v", err)
+	}
+	_, err = s.FavoritePayment(payment.ID, "lunch")
+	if err != nil {
+		t.Fatalf("FavoritePayment(): error = %v", err)
+	}
+	oldDir := t.TempDir()
+	err = s.Export(oldDir)
+	if err != nil {
+		t.Fatalf("Export(): error = %v", err)
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Fatalf("Reject(): error = %v", err)
+	}
+	_, err = s.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Fatalf("RegisterAccount(): error = %v", err)
+	}
+	s.favorites = nil
+	newDir := t.TempDir()
+	err = s.Export(newDir)
+	if err != nil {
+		t.Fatalf("Export(): error = %v", err)
+	}
+	return oldDir, newDir, payment
+}
+
+func TestDiffSnapshots_success(t *testing.T) {
+	oldDir, newDir, payment := newSnapshotTestDirs(t)
+
+	diff, err := DiffSnapshots(oldDir, newDir)
+	if err != nil {
+		t.Errorf("DiffSnapshots(): error = %v", err)
+		return
+	}
+	if len(diff.Accounts) != 2 || diff.Accounts[0].Kind != ChangeChanged || diff.Accounts[0].New.Balance != 10_000 || diff.Accounts[1].Kind != ChangeAdded {
+		t.Errorf("DiffSnapshots(): wrong account changes = %v", diff.Accounts)
+		return
+	}
+	if len(diff.Payments) != 1 || diff.Payments[0].ID != payment.ID || diff.Payments[0].New.Status != types.PaymentStatusFail {
+		t.Errorf("DiffSnapshots(): wrong payment changes = %v", diff.Payments)
+		return
+	}
+	if len(diff.Favorites) != 1 || diff.Favorites[0].Kind != ChangeRemoved {
+		t.Errorf("DiffSnapshots(): wrong favorite changes = %v", diff.Favorites)
+		return
+	}
+
+	diff, err = DiffSnapshots(newDir, newDir)
+	if err != nil || !diff.Empty() {
+		t.Errorf("DiffSnapshots(): expected no changes, actual: %v, error = %v", diff, err)
+	}
+}
+
+func TestService_Merge_policies(t *testing.T) {
+	oldDir, newDir, payment := newSnapshotTestDirs(t)
+
+	load := func() *Service {
+		s, err := loadSnapshot(oldDir)
+		if err != nil {
+			t.Fatalf("loadSnapshot(): error = %v", err)
+		}
+		return s
+	}
+
+	s := load()
+	conflicts, err := s.Merge(newDir, MergeFail)
+	if err != ErrMergeConflict || len(conflicts) != 2 {
+		t.Errorf("Merge(): wrong result for fail policy, conflicts = %v, error = %v", conflicts, err)
+		return
+	}
+	if len(s.accounts) != 1 {
+		t.Errorf("Merge(): state changed on conflict, accounts = %v", s.accounts)
+		return
+	}
+
+	s = load()
+	_, err = s.Merge(newDir, MergeOurs)
+	if err != nil {
+		t.Errorf("Merge(): error = %v", err)
+		return
+	}
+	merged, _ := s.FindPaymentByID(payment.ID)
+	if len(s.accounts) != 2 || len(s.favorites) != 1 || merged.Status != types.PaymentStatusInProgress {
+		t.Errorf("Merge(): ours not kept, accounts = %v, payment = %v", s.accounts, merged)
+		return
+	}
+	account, err := s.RegisterAccount("+992000000003")
+	if err != nil || account.ID != 3 {
+		t.Errorf("RegisterAccount(): wrong account after merge = %v, error = %v", account, err)
+		return
+	}
+
+	s = load()
+	_, err = s.Merge(newDir, MergeTheirs)
+	if err != nil {
+		t.Errorf("Merge(): error = %v", err)
+		return
+	}
+	merged, _ = s.FindPaymentByID(payment.ID)
+	if merged.Status != types.PaymentStatusFail || s.accounts[0].Balance != 10_000 {
+		t.Errorf("Merge(): theirs not applied, payment = %v, account = %v", merged, s.accounts[0])
+		return
+	}
+
+	_, err = s.Merge(newDir, "newest")
+	if err != ErrInvalidMergePolicy {
+		t.Errorf("Merge(): wrong error for unknown policy = %v", err)
+	}
+}
+
+func TestService_Merge_otherOwner(t *testing.T) {
+	// в двух состояниях счёт 1 принадлежит разным клиентам
+	theirs := newTestService()
+	account, err := theirs.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Fatalf("RegisterAccount(): error = %v", err)
+	}
+	err = theirs.Deposit(account.ID, 10_000)
+	if err != nil {
+		t.Fatalf("Deposit(): error = %v", err)
+	}
+	payment, err := theirs.Pay(account.ID, 1_000, "food")
+	if err != nil {
+		t.Fatalf("Pay(): error = %v", err)
+	}
+	dir := t.TempDir()
+	err = theirs.Export(dir)
+	if err != nil {
+		t.Fatalf("Export(): error = %v", err)
+	}
+
+	s := newTestService()
+	_, err = s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount(): error = %v", err)
+	}
+	conflicts, err := s.Merge(dir, MergeOurs)
+	if err != nil {
+		t.Errorf("Merge(): error = %v", err)
+		return
+	}
+	if len(conflicts) != 2 || conflicts[1].Kind != "payment" || conflicts[1].ID != payment.ID || conflicts[1].Ours != nil {
+		t.Errorf("Merge(): wrong conflicts = %v", conflicts)
+		return
+	}
+	if _, err := s.FindPaymentByID(payment.ID); err != ErrPaymentNotFound {
+		t.Errorf("Merge(): payment of another owner merged, error = %v", err)
+		return
+	}
+
+	_, err = s.Merge(dir, MergeTheirs)
+	if err != nil {
+		t.Errorf("Merge(): error = %v", err)
+		return
+	}
+	merged, err := s.FindPaymentByID(payment.ID)
+	if err != nil || s.accounts[0].Phone != "+992000000002" || merged.AccountID != s.accounts[0].ID {
+		t.Errorf("Merge(): theirs not applied, payment = %v, accounts = %v, error = %v", merged, s.accounts, err)
+	}
+}
+
+func TestService_Merge_theirsPhoneTaken(t *testing.T) {
+	// в их состоянии счёт 1 переехал на телефон, который у нас занят счётом 3
+	theirs := newTestService()
+	for _, phone := range []types.Phone{"+992926421503", "+992926421502"} {
+		if _, err := theirs.RegisterAccount(phone); err != nil {
+			t.Fatalf("RegisterAccount(): error = %v", err)
+		}
+	}
+	dir := t.TempDir()
+	err := theirs.Export(dir)
+	if err != nil {
+		t.Fatalf("Export(): error = %v", err)
+	}
+
+	s := newTestService()
+	for _, phone := range []types.Phone{"+992926421501", "+992926421502", "+992926421503"} {
+		if _, err := s.RegisterAccount(phone); err != nil {
+			t.Fatalf("RegisterAccount(): error = %v", err)
+		}
+	}
+	conflicts, err := s.Merge(dir, MergeTheirs)
+	if err != ErrMergeConflict || len(conflicts) != 2 || conflicts[1].ID != "1" || conflicts[1].Ours.(types.Account).ID != 3 {
+		t.Errorf("Merge(): wrong result, conflicts = %v, error = %v", conflicts, err)
+		return
+	}
+	if s.accounts[0].Phone != "+992926421501" {
+		t.Errorf("Merge(): state changed on conflict, accounts = %v", s.accounts)
+	}
+}
@@ -0,0 +1,200 @@
+package wallet
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+)
+
+var ErrInvalidPeriod = errors.New("invalid statement period")
+
+// StatementLine - операция в выписке и баланс счёта после неё
+type StatementLine struct {
+	Time     time.Time             `json:"time"`
+	Kind     RecordKind            `json:"kind"`
+	ID       string                `json:"id"`
+	Category types.PaymentCategory `json:"category,omitempty"`
+	Amount   types.Money           `json:"amount"`
+	Balance  types.Money           `json:"balance"`
+}
+
+// CategoryTotal - сколько потрачено в категории за период с учётом возвратов и отмен
+type CategoryTotal struct {
+	Category types.PaymentCategory `json:"category"`
+	Spent    types.Money           `json:"spent"`
+}
+
+// Statement - выписка по счёту за период [From, To)
+type Statement struct {
+	AccountID      int64           `json:"account_id"`
+	Phone          types.Phone     `json:"phone"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance types.Money     `json:"opening_balance"`
+	ClosingBalance types.Money     `json:"closing_balance"`
+	TotalIn        types.Money     `json:"total_in"`
+	TotalOut       types.Money     `json:"total_out"`
+	Lines          []StatementLine `json:"lines"`
+	Categories     []CategoryTotal `json:"categories"`
+}
+
+// Statement строит выписку по счёту за период [from, to): входящий баланс, все пополнения,
+// платежи, комиссии, возвраты и отмены за период, исходящий баланс и итоги по категориям.
+// Входящий баланс совпадает с BalanceAt, корректировки в выписку не попадают.
+func (s *Service) Statement(accountID int64, from time.Time, to time.Time) (*Statement, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, ErrInvalidPeriod
+	}
+
+	statement := &Statement{
+		AccountID: account.ID,
+		Phone:     account.Phone,
+		From:      from,
+		To:        to,
+	}
+
+	// входящий баланс берётся из реального баланса счёта, а не из суммы истории:
+	// у счетов из старых дампов баланс есть, а пополнений нет
+	statement.OpeningBalance = s.balanceAt(account, from)
+	var records []BalanceRecord
+	for _, record := range s.balanceRecords(account.ID) {
+		// корректировка только объясняет баланс и не меняет его
+		if record.Kind == RecordAdjustment || record.Time.Before(from) {
+			continue
+		}
+		if !record.Time.Before(to) {
+			break
+		}
+		// balanceAt(from) уже учёл записи, сделанные ровно в from
+		if record.Time.Equal(from) {
+			statement.OpeningBalance -= record.Amount
+		}
+		records = append(records, record)
+	}
+
+	spent := make(map[types.PaymentCategory]types.Money)
+	statement.ClosingBalance = statement.OpeningBalance
+	for _, record := range records {
+		statement.ClosingBalance += record.Amount
+		if record.Amount > 0 {
+			statement.TotalIn += record.Amount
+		} else {
+			statement.TotalOut -= record.Amount
+		}
+		switch record.Kind {
+		case RecordPayment, RecordFee, RecordRefund, RecordReject:
+			spent[record.Category] -= record.Amount
+		}
+
+		statement.Lines = append(statement.Lines, StatementLine{
+			Time:     record.Time,
+			Kind:     record.Kind,
+			ID:       record.ID,
+			Category: record.Category,
+			Amount:   record.Amount,
+			Balance:  statement.ClosingBalance,
+		})
+	}
+
+	for category, amount := range spent {
+		statement.Categories = append(statement.Categories, CategoryTotal{Category: category, Spent: amount})
+	}
+	sort.Slice(statement.Categories, func(i, j int) bool {
+		return statement.Categories[i].Category < statement.Categories[j].Category
+	})
+	return statement, nil
+}
+
+// WriteText выводит выписку простым текстом с выровненными колонками
+func (st *Statement) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Statement for account %d (%s)\n", st.AccountID, st.Phone)
+	fmt.Fprintf(tw, "Period: %s - %s\n\n", st.From.Format(time.DateOnly), st.To.Format(time.DateOnly))
+	fmt.Fprintf(tw, "Opening balance\t\t\t%d\n", st.OpeningBalance)
+	fmt.Fprintf(tw, "Date\tOperation\tCategory\tAmount\tBalance\n")
+	for _, line := range st.Lines {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\n", line.Time.Format(time.DateTime), line.Kind, line.Category, line.Amount, line.Balance)
+	}
+	fmt.Fprintf(tw, "Closing balance\t\t\t%d\n\n", st.ClosingBalance)
+	fmt.Fprintf(tw, "Total in\t%d\nTotal out\t%d\n\n", st.TotalIn, st.TotalOut)
+	fmt.Fprintf(tw, "Category\tSpent\n")
+	for _, total := range st.Categories {
+		fmt.Fprintf(tw, "%s\t%d\n", total.Category, total.Spent)
+	}
+	return tw.Flush()
+}
+
+var statementTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"date":     func(t time.Time) string { return t.Format(time.DateOnly) },
+	"datetime": func(t time.Time) string { return t.Format(time.DateTime) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Statement {{.AccountID}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #999; padding: 2px 8px; }
+td.amount { text-align: right; }
+@media print { body { font-size: 10pt; } }
+</style>
+</head>
+<body>
+<h1>Statement for account {{.AccountID}} ({{.Phone}})</h1>
+<p>Period: {{date .From}} &ndash; {{date .To}}</p>
+<table>
+<tr><th>Date</th><th>Operation</th><th>Category</th><th>Amount</th><th>Balance</th></tr>
+<tr><td colspan="4">Opening balance</td><td class="amount">{{.OpeningBalance}}</td></tr>
+{{- range .Lines}}
+<tr><td>{{datetime .Time}}</td><td>{{.Kind}}</td><td>{{.Category}}</td><td class="amount">{{.Amount}}</td><td class="amount">{{.Balance}}</td></tr>
+{{- end}}
+<tr><td colspan="4">Closing balance</td><td class="amount">{{.ClosingBalance}}</td></tr>
+</table>
+<p>Total in: {{.TotalIn}}, total out: {{.TotalOut}}</p>
+<table>
+<tr><th>Category</th><th>Spent</th></tr>
+{{- range .Categories}}
+<tr><td>{{.Category}}</td><td class="amount">{{.Spent}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// WriteHTML выводит выписку страницей HTML, пригодной для печати
+func (st *Statement) WriteHTML(w io.Writer) error {
+	return statementTemplate.Execute(w, st)
+}
+
+// WriteCSV выводит операции выписки в CSV; входящий и исходящий балансы - первой и последней строкой
+func (st *Statement) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "kind", "id", "category", "amount", "balance"})
+	cw.Write([]string{st.From.Format(time.RFC3339), "OPENING", "", "", "", strconv.FormatInt(int64(st.OpeningBalance), 10)})
+	for _, line := range st.Lines {
+		cw.Write([]string{
+			line.Time.Format(time.RFC3339),
+			string(line.Kind),
+			line.ID,
+			string(line.Category),
+			strconv.FormatInt(int64(line.Amount), 10),
+			strconv.FormatInt(int64(line.Balance), 10),
+		})
+	}
+	cw.Write([]string{st.To.Format(time.RFC3339), "CLOSING", "", "", "", strconv.FormatInt(int64(st.ClosingBalance), 10)})
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,174 @@
+package wallet
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_Statement_success(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2024, 4, 20, 10, 0, 0, 0, time.UTC)}
+	s.SetClock(clock.Now)
+	s.SetFeeSchedule(FeeSchedule{Rules: []FeeRule{{Category: "taxi", Fixed: 10}}})
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 10_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 1_000, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	clock.now = time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC)
+	err = s.Deposit(account.ID, 500)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	payment, err := s.Pay(account.ID, 2_000, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 300, "taxi")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	clock.now = clock.now.Add(time.Hour)
+	_, err = s.Refund(payment.ID, 500)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	clock.now = time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	_, err = s.Pay(account.ID, 100, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	statement, err := s.Statement(account.ID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		t.Errorf("Statement(): error = %v", err)
+		return
+	}
+	if statement.OpeningBalance != 9_000 || statement.ClosingBalance != 7_690 {
+		t.Errorf("Statement(): wrong balances, opening = %d, closing = %d", statement.OpeningBalance, statement.ClosingBalance)
+		return
+	}
+	if statement.TotalIn != 1_000 || statement.TotalOut != 2_310 || len(statement.Lines) != 5 {
+		t.Errorf("Statement(): wrong totals = %d/%d, lines = %v", statement.TotalIn, statement.TotalOut, statement.Lines)
+		return
+	}
+	if last := statement.Lines[len(statement.Lines)-1]; last.Kind != RecordRefund || last.Balance != statement.ClosingBalance {
+		t.Errorf("Statement(): wrong last line = %v", last)
+		return
+	}
+	if len(statement.Categories) != 2 || statement.Categories[0].Spent != 1_500 || statement.Categories[1].Spent != 310 {
+		t.Errorf("Statement(): wrong category totals = %v", statement.Categories)
+		return
+	}
+
+	_, err = s.Statement(account.ID, from, from)
+	if err != ErrInvalidPeriod {
+		t.Errorf("Statement(): wrong error for empty period = %v", err)
+	}
+}
+
+func TestService_Statement_legacyBalance(t *testing.T) {
+	// счёт из старого дампа: баланс есть, пополнений в истории нет
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "accounts.dump"), []byte("1;+992926421505;1000\n"), 0666)
+	if err != nil {
+		t.Fatalf("WriteFile(): error = %v", err)
+	}
+	s := newTestService()
+	err = s.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	clock := &testClock{now: time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC)}
+	s.SetClock(clock.Now)
+	_, err = s.Pay(1, 100, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	statement, err := s.Statement(1, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		t.Errorf("Statement(): error = %v", err)
+		return
+	}
+	if statement.OpeningBalance != 1_000 || statement.ClosingBalance != 900 || len(statement.Lines) != 1 {
+		t.Errorf("Statement(): wrong balances, opening = %d, closing = %d, lines = %v", statement.OpeningBalance, statement.ClosingBalance, statement.Lines)
+	}
+}
+
+func TestStatement_Write(t *testing.T) {
+	s := newTestService()
+	s.SetClock((&testClock{now: time.Date(2024, 5, 3, 10, 0, 0, 0, time.UTC)}).Now)
+
+	account, err := s.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+	err = s.Deposit(account.ID, 1_000)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 250, "<food>")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	statement, err := s.Statement(account.ID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		t.Errorf("Statement(): error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name  string
+		write func(*bytes.Buffer) error
+		want  []string
+	}{
+		{"text", func(b *bytes.Buffer) error { return statement.WriteText(b) }, []string{"Opening balance", "2024-05-03 10:00:00", "<food>", "Closing balance"}},
+		{"html", func(b *bytes.Buffer) error { return statement.WriteHTML(b) }, []string{"<table>", "&lt;food&gt;", ">750<"}},
+		{"csv", func(b *bytes.Buffer) error { return statement.WriteCSV(b) }, []string{"time,kind,id,category,amount,balance", ",PAYMENT,", ",-250,750", "CLOSING,,,,750"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := tt.write(&buf)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s: %q not found in:\n%s", tt.name, want, buf.String())
+			}
+		}
+	}
+}
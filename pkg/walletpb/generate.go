@@ -0,0 +1,4 @@
+// Package walletpb содержит сгенерированный из api/proto/wallet.proto код gRPC API кошелька.
+package walletpb
+
+//go:generate protoc -I ../../api/proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative wallet.proto
@@ -0,0 +1,858 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: wallet.proto
+
+package walletpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Wallet_RegisterAccount_FullMethodName         = "/wallet.v1.Wallet/RegisterAccount"
+	Wallet_FindAccountByID_FullMethodName         = "/wallet.v1.Wallet/FindAccountByID"
+	Wallet_Deposit_FullMethodName                 = "/wallet.v1.Wallet/Deposit"
+	Wallet_FreezeAccount_FullMethodName           = "/wallet.v1.Wallet/FreezeAccount"
+	Wallet_UnfreezeAccount_FullMethodName         = "/wallet.v1.Wallet/UnfreezeAccount"
+	Wallet_CloseAccount_FullMethodName            = "/wallet.v1.Wallet/CloseAccount"
+	Wallet_Pay_FullMethodName                     = "/wallet.v1.Wallet/Pay"
+	Wallet_FindPaymentByID_FullMethodName         = "/wallet.v1.Wallet/FindPaymentByID"
+	Wallet_Reject_FullMethodName                  = "/wallet.v1.Wallet/Reject"
+	Wallet_Repeat_FullMethodName                  = "/wallet.v1.Wallet/Repeat"
+	Wallet_RefundPayment_FullMethodName           = "/wallet.v1.Wallet/RefundPayment"
+	Wallet_FavoritePayment_FullMethodName         = "/wallet.v1.Wallet/FavoritePayment"
+	Wallet_FindFavoriteByID_FullMethodName        = "/wallet.v1.Wallet/FindFavoriteByID"
+	Wallet_ListFavorites_FullMethodName           = "/wallet.v1.Wallet/ListFavorites"
+	Wallet_UpdateFavorite_FullMethodName          = "/wallet.v1.Wallet/UpdateFavorite"
+	Wallet_DeleteFavorite_FullMethodName          = "/wallet.v1.Wallet/DeleteFavorite"
+	Wallet_PayFromFavorite_FullMethodName         = "/wallet.v1.Wallet/PayFromFavorite"
+	Wallet_SumPayments_FullMethodName             = "/wallet.v1.Wallet/SumPayments"
+	Wallet_SumPaymentsWithProgress_FullMethodName = "/wallet.v1.Wallet/SumPaymentsWithProgress"
+	Wallet_ExportAccountHistory_FullMethodName    = "/wallet.v1.Wallet/ExportAccountHistory"
+)
+
+// WalletClient is the client API for Wallet service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Wallet повторяет операции wallet.Service. Суммы передаются в минимальных единицах.
+type WalletClient interface {
+	RegisterAccount(ctx context.Context, in *RegisterAccountRequest, opts ...grpc.CallOption) (*Account, error)
+	FindAccountByID(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Account, error)
+	Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*Account, error)
+	FreezeAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Account, error)
+	UnfreezeAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Account, error)
+	CloseAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Account, error)
+	Pay(ctx context.Context, in *PayRequest, opts ...grpc.CallOption) (*Payment, error)
+	FindPaymentByID(ctx context.Context, in *PaymentRequest, opts ...grpc.CallOption) (*Payment, error)
+	Reject(ctx context.Context, in *PaymentRequest, opts ...grpc.CallOption) (*Payment, error)
+	Repeat(ctx context.Context, in *PaymentRequest, opts ...grpc.CallOption) (*Payment, error)
+	RefundPayment(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*Refund, error)
+	FavoritePayment(ctx context.Context, in *FavoritePaymentRequest, opts ...grpc.CallOption) (*Favorite, error)
+	FindFavoriteByID(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*Favorite, error)
+	ListFavorites(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*FavoriteList, error)
+	UpdateFavorite(ctx context.Context, in *UpdateFavoriteRequest, opts ...grpc.CallOption) (*Favorite, error)
+	DeleteFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*DeleteFavoriteResponse, error)
+	PayFromFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*Payment, error)
+	SumPayments(ctx context.Context, in *SumPaymentsRequest, opts ...grpc.CallOption) (*SumPaymentsResponse, error)
+	// SumPaymentsWithProgress отдаёт промежуточные суммы по частям платежей
+	SumPaymentsWithProgress(ctx context.Context, in *SumPaymentsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Progress], error)
+	// ExportAccountHistory отдаёт историю счёта, включая возвраты, по одной записи
+	ExportAccountHistory(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Payment], error)
+}
+
+type walletClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWalletClient(cc grpc.ClientConnInterface) WalletClient {
+	return &walletClient{cc}
+}
+
+func (c *walletClient) RegisterAccount(ctx context.Context, in *RegisterAccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Wallet_RegisterAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) FindAccountByID(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Wallet_FindAccountByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Wallet_Deposit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) FreezeAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Wallet_FreezeAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) UnfreezeAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Wallet_UnfreezeAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) CloseAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Wallet_CloseAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) Pay(ctx context.Context, in *PayRequest, opts ...grpc.CallOption) (*Payment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Payment)
+	err := c.cc.Invoke(ctx, Wallet_Pay_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) FindPaymentByID(ctx context.Context, in *PaymentRequest, opts ...grpc.CallOption) (*Payment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Payment)
+	err := c.cc.Invoke(ctx, Wallet_FindPaymentByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) Reject(ctx context.Context, in *PaymentRequest, opts ...grpc.CallOption) (*Payment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Payment)
+	err := c.cc.Invoke(ctx, Wallet_Reject_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) Repeat(ctx context.Context, in *PaymentRequest, opts ...grpc.CallOption) (*Payment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Payment)
+	err := c.cc.Invoke(ctx, Wallet_Repeat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) RefundPayment(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*Refund, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Refund)
+	err := c.cc.Invoke(ctx, Wallet_RefundPayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) FavoritePayment(ctx context.Context, in *FavoritePaymentRequest, opts ...grpc.CallOption) (*Favorite, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Favorite)
+	err := c.cc.Invoke(ctx, Wallet_FavoritePayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) FindFavoriteByID(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*Favorite, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Favorite)
+	err := c.cc.Invoke(ctx, Wallet_FindFavoriteByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) ListFavorites(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*FavoriteList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FavoriteList)
+	err := c.cc.Invoke(ctx, Wallet_ListFavorites_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) UpdateFavorite(ctx context.Context, in *UpdateFavoriteRequest, opts ...grpc.CallOption) (*Favorite, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Favorite)
+	err := c.cc.Invoke(ctx, Wallet_UpdateFavorite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) DeleteFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*DeleteFavoriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteFavoriteResponse)
+	err := c.cc.Invoke(ctx, Wallet_DeleteFavorite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) PayFromFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*Payment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Payment)
+	err := c.cc.Invoke(ctx, Wallet_PayFromFavorite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) SumPayments(ctx context.Context, in *SumPaymentsRequest, opts ...grpc.CallOption) (*SumPaymentsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SumPaymentsResponse)
+	err := c.cc.Invoke(ctx, Wallet_SumPayments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) SumPaymentsWithProgress(ctx context.Context, in *SumPaymentsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Progress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Wallet_ServiceDesc.Streams[0], Wallet_SumPaymentsWithProgress_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SumPaymentsRequest, Progress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Wallet_SumPaymentsWithProgressClient = grpc.ServerStreamingClient[Progress]
+
+func (c *walletClient) ExportAccountHistory(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Payment], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Wallet_ServiceDesc.Streams[1], Wallet_ExportAccountHistory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AccountRequest, Payment]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Wallet_ExportAccountHistoryClient = grpc.ServerStreamingClient[Payment]
+
+// WalletServer is the server API for Wallet service.
+// All implementations must embed UnimplementedWalletServer
+// for forward compatibility.
+//
+// Wallet повторяет операции wallet.Service. Суммы передаются в минимальных единицах.
+type WalletServer interface {
+	RegisterAccount(context.Context, *RegisterAccountRequest) (*Account, error)
+	FindAccountByID(context.Context, *AccountRequest) (*Account, error)
+	Deposit(context.Context, *DepositRequest) (*Account, error)
+	FreezeAccount(context.Context, *AccountRequest) (*Account, error)
+	UnfreezeAccount(context.Context, *AccountRequest) (*Account, error)
+	CloseAccount(context.Context, *AccountRequest) (*Account, error)
+	Pay(context.Context, *PayRequest) (*Payment, error)
+	FindPaymentByID(context.Context, *PaymentRequest) (*Payment, error)
+	Reject(context.Context, *PaymentRequest) (*Payment, error)
+	Repeat(context.Context, *PaymentRequest) (*Payment, error)
+	RefundPayment(context.Context, *RefundRequest) (*Refund, error)
+	FavoritePayment(context.Context, *FavoritePaymentRequest) (*Favorite, error)
+	FindFavoriteByID(context.Context, *FavoriteRequest) (*Favorite, error)
+	ListFavorites(context.Context, *AccountRequest) (*FavoriteList, error)
+	UpdateFavorite(context.Context, *UpdateFavoriteRequest) (*Favorite, error)
+	DeleteFavorite(context.Context, *FavoriteRequest) (*DeleteFavoriteResponse, error)
+	PayFromFavorite(context.Context, *FavoriteRequest) (*Payment, error)
+	SumPayments(context.Context, *SumPaymentsRequest) (*SumPaymentsResponse, error)
+	// SumPaymentsWithProgress отдаёт промежуточные суммы по частям платежей
+	SumPaymentsWithProgress(*SumPaymentsRequest, grpc.ServerStreamingServer[Progress]) error
+	// ExportAccountHistory отдаёт историю счёта, включая возвраты, по одной записи
+	ExportAccountHistory(*AccountRequest, grpc.ServerStreamingServer[Payment]) error
+	mustEmbedUnimplementedWalletServer()
+}
+
+// UnimplementedWalletServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWalletServer struct{}
+
+func (UnimplementedWalletServer) RegisterAccount(context.Context, *RegisterAccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterAccount not implemented")
+}
+func (UnimplementedWalletServer) FindAccountByID(context.Context, *AccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindAccountByID not implemented")
+}
+func (UnimplementedWalletServer) Deposit(context.Context, *DepositRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deposit not implemented")
+}
+func (UnimplementedWalletServer) FreezeAccount(context.Context, *AccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FreezeAccount not implemented")
+}
+func (UnimplementedWalletServer) UnfreezeAccount(context.Context, *AccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnfreezeAccount not implemented")
+}
+func (UnimplementedWalletServer) CloseAccount(context.Context, *AccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseAccount not implemented")
+}
+func (UnimplementedWalletServer) Pay(context.Context, *PayRequest) (*Payment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pay not implemented")
+}
+func (UnimplementedWalletServer) FindPaymentByID(context.Context, *PaymentRequest) (*Payment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindPaymentByID not implemented")
+}
+func (UnimplementedWalletServer) Reject(context.Context, *PaymentRequest) (*Payment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reject not implemented")
+}
+func (UnimplementedWalletServer) Repeat(context.Context, *PaymentRequest) (*Payment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Repeat not implemented")
+}
+func (UnimplementedWalletServer) RefundPayment(context.Context, *RefundRequest) (*Refund, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundPayment not implemented")
+}
+func (UnimplementedWalletServer) FavoritePayment(context.Context, *FavoritePaymentRequest) (*Favorite, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FavoritePayment not implemented")
+}
+func (UnimplementedWalletServer) FindFavoriteByID(context.Context, *FavoriteRequest) (*Favorite, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindFavoriteByID not implemented")
+}
+func (UnimplementedWalletServer) ListFavorites(context.Context, *AccountRequest) (*FavoriteList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFavorites not implemented")
+}
+func (UnimplementedWalletServer) UpdateFavorite(context.Context, *UpdateFavoriteRequest) (*Favorite, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateFavorite not implemented")
+}
+func (UnimplementedWalletServer) DeleteFavorite(context.Context, *FavoriteRequest) (*DeleteFavoriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFavorite not implemented")
+}
+func (UnimplementedWalletServer) PayFromFavorite(context.Context, *FavoriteRequest) (*Payment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PayFromFavorite not implemented")
+}
+func (UnimplementedWalletServer) SumPayments(context.Context, *SumPaymentsRequest) (*SumPaymentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SumPayments not implemented")
+}
+func (UnimplementedWalletServer) SumPaymentsWithProgress(*SumPaymentsRequest, grpc.ServerStreamingServer[Progress]) error {
+	return status.Errorf(codes.Unimplemented, "method SumPaymentsWithProgress not implemented")
+}
+func (UnimplementedWalletServer) ExportAccountHistory(*AccountRequest, grpc.ServerStreamingServer[Payment]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportAccountHistory not implemented")
+}
+func (UnimplementedWalletServer) mustEmbedUnimplementedWalletServer() {}
+func (UnimplementedWalletServer) testEmbeddedByValue()                {}
+
+// UnsafeWalletServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WalletServer will
+// result in compilation errors.
+type UnsafeWalletServer interface {
+	mustEmbedUnimplementedWalletServer()
+}
+
+func RegisterWalletServer(s grpc.ServiceRegistrar, srv WalletServer) {
+	// If the following call pancis, it indicates UnimplementedWalletServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Wallet_ServiceDesc, srv)
+}
+
+func _Wallet_RegisterAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).RegisterAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_RegisterAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).RegisterAccount(ctx, req.(*RegisterAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_FindAccountByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).FindAccountByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_FindAccountByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).FindAccountByID(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_Deposit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DepositRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).Deposit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_Deposit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).Deposit(ctx, req.(*DepositRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_FreezeAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).FreezeAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_FreezeAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).FreezeAccount(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_UnfreezeAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).UnfreezeAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_UnfreezeAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).UnfreezeAccount(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_CloseAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).CloseAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_CloseAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).CloseAccount(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_Pay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).Pay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_Pay_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).Pay(ctx, req.(*PayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_FindPaymentByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).FindPaymentByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_FindPaymentByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).FindPaymentByID(ctx, req.(*PaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_Reject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).Reject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_Reject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).Reject(ctx, req.(*PaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_Repeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).Repeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_Repeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).Repeat(ctx, req.(*PaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_RefundPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).RefundPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_RefundPayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).RefundPayment(ctx, req.(*RefundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_FavoritePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FavoritePaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).FavoritePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_FavoritePayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).FavoritePayment(ctx, req.(*FavoritePaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_FindFavoriteByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FavoriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).FindFavoriteByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_FindFavoriteByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).FindFavoriteByID(ctx, req.(*FavoriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_ListFavorites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).ListFavorites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_ListFavorites_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).ListFavorites(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_UpdateFavorite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateFavoriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).UpdateFavorite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_UpdateFavorite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).UpdateFavorite(ctx, req.(*UpdateFavoriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_DeleteFavorite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FavoriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).DeleteFavorite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_DeleteFavorite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).DeleteFavorite(ctx, req.(*FavoriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_PayFromFavorite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FavoriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).PayFromFavorite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_PayFromFavorite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).PayFromFavorite(ctx, req.(*FavoriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_SumPayments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SumPaymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).SumPayments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Wallet_SumPayments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).SumPayments(ctx, req.(*SumPaymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_SumPaymentsWithProgress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SumPaymentsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WalletServer).SumPaymentsWithProgress(m, &grpc.GenericServerStream[SumPaymentsRequest, Progress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Wallet_SumPaymentsWithProgressServer = grpc.ServerStreamingServer[Progress]
+
+func _Wallet_ExportAccountHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AccountRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WalletServer).ExportAccountHistory(m, &grpc.GenericServerStream[AccountRequest, Payment]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Wallet_ExportAccountHistoryServer = grpc.ServerStreamingServer[Payment]
+
+// Wallet_ServiceDesc is the grpc.ServiceDesc for Wallet service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Wallet_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wallet.v1.Wallet",
+	HandlerType: (*WalletServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegisterAccount",
+			Handler:    _Wallet_RegisterAccount_Handler,
+		},
+		{
+			MethodName: "FindAccountByID",
+			Handler:    _Wallet_FindAccountByID_Handler,
+		},
+		{
+			MethodName: "Deposit",
+			Handler:    _Wallet_Deposit_Handler,
+		},
+		{
+			MethodName: "FreezeAccount",
+			Handler:    _Wallet_FreezeAccount_Handler,
+		},
+		{
+			MethodName: "UnfreezeAccount",
+			Handler:    _Wallet_UnfreezeAccount_Handler,
+		},
+		{
+			MethodName: "CloseAccount",
+			Handler:    _Wallet_CloseAccount_Handler,
+		},
+		{
+			MethodName: "Pay",
+			Handler:    _Wallet_Pay_Handler,
+		},
+		{
+			MethodName: "FindPaymentByID",
+			Handler:    _Wallet_FindPaymentByID_Handler,
+		},
+		{
+			MethodName: "Reject",
+			Handler:    _Wallet_Reject_Handler,
+		},
+		{
+			MethodName: "Repeat",
+			Handler:    _Wallet_Repeat_Handler,
+		},
+		{
+			MethodName: "RefundPayment",
+			Handler:    _Wallet_RefundPayment_Handler,
+		},
+		{
+			MethodName: "FavoritePayment",
+			Handler:    _Wallet_FavoritePayment_Handler,
+		},
+		{
+			MethodName: "FindFavoriteByID",
+			Handler:    _Wallet_FindFavoriteByID_Handler,
+		},
+		{
+			MethodName: "ListFavorites",
+			Handler:    _Wallet_ListFavorites_Handler,
+		},
+		{
+			MethodName: "UpdateFavorite",
+			Handler:    _Wallet_UpdateFavorite_Handler,
+		},
+		{
+			MethodName: "DeleteFavorite",
+			Handler:    _Wallet_DeleteFavorite_Handler,
+		},
+		{
+			MethodName: "PayFromFavorite",
+			Handler:    _Wallet_PayFromFavorite_Handler,
+		},
+		{
+			MethodName: "SumPayments",
+			Handler:    _Wallet_SumPayments_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SumPaymentsWithProgress",
+			Handler:       _Wallet_SumPaymentsWithProgress_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExportAccountHistory",
+			Handler:       _Wallet_ExportAccountHistory_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "wallet.proto",
+}
@@ -0,0 +1,372 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+	"github.com/Habibullo-1999/wallet/pkg/wallet"
+	"github.com/google/uuid"
+)
+
+const (
+	SignatureHeader = "X-Wallet-Signature"
+	TimestampHeader = "X-Wallet-Timestamp"
+	EventHeader     = "X-Wallet-Event"
+)
+
+var ErrEndpointNotFound = errors.New("endpoint not found")
+var ErrDeliveryNotFound = errors.New("delivery not found")
+
+// DeliveryStatus представляет собой состояние доставки вебхука
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"
+	DeliveryDelivered DeliveryStatus = "DELIVERED"
+	DeliveryDead      DeliveryStatus = "DEAD"
+)
+
+// Endpoint - адрес мерчанта, получающий события по платежам в его категориях
+type Endpoint struct {
+	ID         string
+	URL        string
+	Secret     string
+	Categories []types.PaymentCategory
+}
+
+// Delivery - одно событие для одного адреса со всеми попытками отправки
+type Delivery struct {
+	ID          string
+	EndpointID  string
+	Event       wallet.EventType
+	Payload     []byte
+	Status      DeliveryStatus
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string
+}
+
+// Attempt - запись журнала об одной попытке отправки
+type Attempt struct {
+	DeliveryID string
+	EndpointID string
+	Time       time.Time
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+}
+
+// Payload - тело запроса, которое получает мерчант
+type Payload struct {
+	ID      string           `json:"id"`
+	Event   wallet.EventType `json:"event"`
+	Payment types.Payment    `json:"payment"`
+	// Amount - сумма, вернувшаяся покупателю при отмене платежа
+	Amount types.Money `json:"amount,omitempty"`
+}
+
+// Dispatcher отправляет события о завершённых и отменённых платежах на адреса мерчантов.
+// Неудачные попытки повторяются с экспоненциальной задержкой, после MaxAttempts
+// доставка попадает в очередь недоставленных (DeadLetters).
+type Dispatcher struct {
+	client *http.Client
+	now    func() time.Time
+	wake   chan struct{}
+
+	// MaxAttempts - сколько раз пытаться доставить событие
+	MaxAttempts int
+	// Backoff - задержка перед второй попыткой, дальше она удваивается
+	Backoff time.Duration
+
+	mu         sync.Mutex
+	endpoints  []*Endpoint
+	deliveries []*Delivery
+	attempts   []Attempt
+}
+
+func NewDispatcher(client *http.Client) *Dispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Dispatcher{
+		client:      client,
+		now:         time.Now,
+		wake:        make(chan struct{}, 1),
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+	}
+}
+
+// Register добавляет адрес для событий по платежам в категориях categories
+func (d *Dispatcher) Register(url string, secret string, categories ...types.PaymentCategory) *Endpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	endpoint := &Endpoint{
+		ID:         uuid.New().String(),
+		URL:        url,
+		Secret:     secret,
+		Categories: categories,
+	}
+	d.endpoints = append(d.endpoints, endpoint)
+	return endpoint
+}
+
+func (d *Dispatcher) Unregister(endpointID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i, endpoint := range d.endpoints {
+		if endpoint.ID == endpointID {
+			d.endpoints = append(d.endpoints[:i], d.endpoints[i+1:]...)
+			return nil
+		}
+	}
+	return ErrEndpointNotFound
+}
+
+// Handle ставит событие в очередь доставки; подходит как обработчик для wallet.Service.Subscribe
+func (d *Dispatcher) Handle(event wallet.Event) {
+	var payload Payload
+	switch e := event.(type) {
+	case wallet.PaymentCompleted:
+		payload = Payload{Event: e.Type(), Payment: e.Payment}
+	case wallet.PaymentRejected:
+		payload = Payload{Event: e.Type(), Payment: e.Payment, Amount: e.Amount}
+	default:
+		return
+	}
+
+	d.mu.Lock()
+	for _, endpoint := range d.endpoints {
+		if !endpoint.accepts(payload.Payment.Category) {
+			continue
+		}
+
+		payload.ID = uuid.New().String()
+		body, err := json.Marshal(payload)
+		if err != nil {
+			continue
+		}
+		d.deliveries = append(d.deliveries, &Delivery{
+			ID:          payload.ID,
+			EndpointID:  endpoint.ID,
+			Event:       payload.Event,
+			Payload:     body,
+			Status:      DeliveryPending,
+			NextAttempt: d.now(),
+		})
+	}
+	d.mu.Unlock()
+
+	d.notify()
+}
+
+// Run доставляет события, пока не отменён ctx
+func (d *Dispatcher) Run(ctx context.Context) error {
+	for {
+		wait := d.deliverDue(ctx)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-d.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// Deliveries возвращает копии всех доставок
+func (d *Dispatcher) Deliveries() []Delivery {
+	return d.filter(func(delivery *Delivery) bool { return true })
+}
+
+// DeadLetters возвращает доставки, исчерпавшие все попытки
+func (d *Dispatcher) DeadLetters() []Delivery {
+	return d.filter(func(delivery *Delivery) bool { return delivery.Status == DeliveryDead })
+}
+
+// Log возвращает журнал всех попыток доставки
+func (d *Dispatcher) Log() []Attempt {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	attempts := make([]Attempt, len(d.attempts))
+	copy(attempts, d.attempts)
+	return attempts
+}
+
+// Redeliver возвращает недоставленное событие в очередь с обнулённым счётчиком попыток
+func (d *Dispatcher) Redeliver(deliveryID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, delivery := range d.deliveries {
+		if delivery.ID == deliveryID && delivery.Status == DeliveryDead {
+			delivery.Status = DeliveryPending
+			delivery.Attempts = 0
+			delivery.NextAttempt = d.now()
+			d.notify()
+			return nil
+		}
+	}
+	return ErrDeliveryNotFound
+}
+
+// Sign подписывает тело запроса: hex(HMAC-SHA256(secret, timestamp + "." + body))
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись запроса на стороне мерчанта
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// deliverDue отправляет наступившие доставки и возвращает время до следующей
+func (d *Dispatcher) deliverDue(ctx context.Context) time.Duration {
+	wait := time.Hour
+	for {
+		d.mu.Lock()
+		now := d.now()
+		var due *Delivery
+		var endpoint Endpoint
+		for _, delivery := range d.deliveries {
+			if delivery.Status != DeliveryPending {
+				continue
+			}
+			if delivery.NextAttempt.After(now) {
+				if left := delivery.NextAttempt.Sub(now); left < wait {
+					wait = left
+				}
+				continue
+			}
+			ep := d.findEndpoint(delivery.EndpointID)
+			if ep == nil {
+				delivery.Status = DeliveryDead
+				delivery.LastError = ErrEndpointNotFound.Error()
+				continue
+			}
+			due, endpoint = delivery, *ep
+			break
+		}
+		var body []byte
+		if due != nil {
+			body = due.Payload
+		}
+		d.mu.Unlock()
+
+		if due == nil || ctx.Err() != nil {
+			return wait
+		}
+
+		attempt := d.send(ctx, endpoint, due.ID, due.Event, body)
+
+		d.mu.Lock()
+		d.attempts = append(d.attempts, attempt)
+		due.Attempts++
+		due.LastError = attempt.Error
+		switch {
+		case attempt.Error == "":
+			due.Status = DeliveryDelivered
+		case due.Attempts >= d.MaxAttempts:
+			due.Status = DeliveryDead
+		default:
+			due.NextAttempt = d.now().Add(d.Backoff << (due.Attempts - 1))
+		}
+		d.mu.Unlock()
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, endpoint Endpoint, deliveryID string, event wallet.EventType, body []byte) Attempt {
+	start := d.now()
+	attempt := Attempt{
+		DeliveryID: deliveryID,
+		EndpointID: endpoint.ID,
+		Time:       start,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, "sha256="+Sign(endpoint.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	attempt.Duration = d.now().Sub(start)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+func (d *Dispatcher) findEndpoint(endpointID string) *Endpoint {
+	for _, endpoint := range d.endpoints {
+		if endpoint.ID == endpointID {
+			return endpoint
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) filter(fn func(delivery *Delivery) bool) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var deliveries []Delivery
+	for _, delivery := range d.deliveries {
+		if fn(delivery) {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+	return deliveries
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (e *Endpoint) accepts(category types.PaymentCategory) bool {
+	if len(e.Categories) == 0 {
+		return true
+	}
+	for _, v := range e.Categories {
+		if v == category {
+			return true
+		}
+	}
+	return false
+}
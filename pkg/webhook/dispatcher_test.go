@@ -0,0 +1,153 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Habibullo-1999/wallet/pkg/types"
+	"github.com/Habibullo-1999/wallet/pkg/wallet"
+)
+
+// waitFor ждёт, пока условие не выполнится, не дольше секунды
+func waitFor(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func newTestWallet(t *testing.T, d *Dispatcher) (*wallet.Service, *types.Account) {
+	svc := &wallet.Service{}
+	svc.Subscribe(d.Handle)
+
+	account, err := svc.RegisterAccount("+992926421505")
+	if err != nil {
+		t.Fatalf("RegisterAccount(): error = %v", err)
+	}
+	err = svc.Deposit(account.ID, 10_000)
+	if err != nil {
+		t.Fatalf("Deposit(): error = %v", err)
+	}
+	return svc, account
+}
+
+func TestDispatcher_retry_success(t *testing.T) {
+	var calls int32
+	payloads := make(chan Payload, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		signature := strings.TrimPrefix(r.Header.Get(SignatureHeader), "sha256=")
+		if !Verify("secret", timestamp, body, signature) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var payload Payload
+		json.Unmarshal(body, &payload)
+		payloads <- payload
+	}))
+	defer ts.Close()
+
+	d := NewDispatcher(ts.Client())
+	d.Backoff = time.Millisecond
+	d.Register(ts.URL, "secret", "shop")
+	svc, account := newTestWallet(t, d)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	payment, err := svc.Pay(account.ID, 1_000, "shop")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	err = svc.CompletePayment(payment.ID)
+	if err != nil {
+		t.Errorf("CompletePayment(): error = %v", err)
+		return
+	}
+
+	delivered := waitFor(func() bool {
+		deliveries := d.Deliveries()
+		return len(deliveries) == 1 && deliveries[0].Status == DeliveryDelivered
+	})
+	if !delivered {
+		t.Errorf("Run(): delivery not delivered: %v", d.Deliveries())
+		return
+	}
+	if len(d.Log()) != 3 {
+		t.Errorf("Log(): expected 3 attempts, actual: %v", d.Log())
+		return
+	}
+	got := <-payloads
+	if got.Event != wallet.EventPaymentCompleted || got.Payment.ID != payment.ID {
+		t.Errorf("Run(): wrong payload = %v", got)
+	}
+}
+
+func TestDispatcher_dead_letter(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	d := NewDispatcher(ts.Client())
+	d.Backoff = time.Millisecond
+	d.MaxAttempts = 2
+	d.Register(ts.URL, "secret", "shop")
+	autoEndpoint := d.Register(ts.URL, "secret", "auto")
+	svc, account := newTestWallet(t, d)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	payment, err := svc.Pay(account.ID, 1_000, "shop")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	err = svc.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	dead := waitFor(func() bool { return len(d.DeadLetters()) == 1 })
+	if !dead {
+		t.Errorf("DeadLetters(): expected one dead delivery, actual: %v", d.Deliveries())
+		return
+	}
+	letter := d.DeadLetters()[0]
+	if letter.EndpointID == autoEndpoint.ID || letter.Attempts != 2 || letter.Event != wallet.EventPaymentRejected {
+		t.Errorf("DeadLetters(): wrong delivery = %v", letter)
+		return
+	}
+
+	err = d.Redeliver(letter.ID)
+	if err != nil {
+		t.Errorf("Redeliver(): error = %v", err)
+		return
+	}
+	again := waitFor(func() bool { return len(d.Log()) == 4 })
+	if !again {
+		t.Errorf("Redeliver(): expected two more attempts, actual: %v", d.Log())
+	}
+}